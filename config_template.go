@@ -0,0 +1,422 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// 用户指定的本地配置模板只能从该目录读取
+	configTemplateDir = "templates"
+	// 配置模板按来源缓存的时间
+	configTemplateCacheTTL = 30 * time.Minute
+)
+
+// 配置模板来源 -> configTemplateCacheEntry
+var configTemplateCache sync.Map
+
+type configTemplateCacheEntry struct {
+	template *ConfigTemplate
+	expires  time.Time
+}
+
+// subconverter格式（ACL4SSR等）的外部配置模板，只使用ruleset和custom_proxy_group
+type ConfigTemplate struct {
+	Rulesets []TemplateRuleset
+	Groups   []TemplateGroup
+}
+
+// ruleset=策略组,规则集  其中规则集可以是带类型前缀的URL，也可以是 []开头的单条规则
+type TemplateRuleset struct {
+	Group    string
+	Rule     string // 单条规则，如 GEOIP,CN、FINAL
+	Type     string // 规则集类型：surge、clash-domain、clash-ipcidr、clash-classic等
+	URL      string
+	Interval int
+}
+
+// custom_proxy_group=名称`类型`成员...[`测速地址`间隔[,超时][,容差]]
+type TemplateGroup struct {
+	Name      string
+	Type      string
+	Members   []TemplateMember
+	URL       string
+	Interval  int
+	Tolerance int
+}
+
+// 代理组成员：以 [] 开头时为策略组或DIRECT/REJECT（Group），否则为匹配节点名称的正则（Pattern）
+type TemplateMember struct {
+	Group   string
+	Pattern string
+
+	// Go正则不支持的表达式（如前瞻断言）为nil，匹配节点时跳过
+	regexp *regexp.Regexp
+}
+
+// 检查用户指定的配置模板：支持http(s)链接或templates目录下的文件名
+func validateTemplateSource(source string) error {
+	if source == "" || isHTTPURL(source) {
+		return nil
+	}
+	if filepath.Base(source) != source || source == "." || source == ".." {
+		return fmt.Errorf("仅支持http(s)链接或%s目录下的文件名: %s", configTemplateDir, source)
+	}
+	return nil
+}
+
+func isHTTPURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// 读取并解析配置模板，source为http(s)链接或本地文件路径
+func loadConfigTemplate(source string) (*ConfigTemplate, error) {
+	var data []byte
+	var err error
+	if isHTTPURL(source) {
+		data, err = fetchURL(source)
+	} else {
+		data, err = os.ReadFile(source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取配置模板失败: %v", err)
+	}
+	return parseConfigTemplate(string(data))
+}
+
+// 解析subconverter格式的INI配置模板
+func parseConfigTemplate(content string) (*ConfigTemplate, error) {
+	tmpl := &ConfigTemplate{}
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "[") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "ruleset", "surge_ruleset":
+			ruleset, err := parseTemplateRuleset(strings.TrimSpace(value))
+			if err != nil {
+				return nil, fmt.Errorf("配置模板第 %d 行: %v", i+1, err)
+			}
+			tmpl.Rulesets = append(tmpl.Rulesets, ruleset)
+		case "custom_proxy_group":
+			group, err := parseTemplateGroup(strings.TrimSpace(value))
+			if err != nil {
+				return nil, fmt.Errorf("配置模板第 %d 行: %v", i+1, err)
+			}
+			tmpl.Groups = append(tmpl.Groups, group)
+		}
+	}
+	if len(tmpl.Groups) == 0 {
+		return nil, fmt.Errorf("配置模板中没有custom_proxy_group")
+	}
+	return tmpl, nil
+}
+
+func parseTemplateRuleset(value string) (TemplateRuleset, error) {
+	group, source, ok := strings.Cut(value, ",")
+	if !ok || strings.TrimSpace(group) == "" {
+		return TemplateRuleset{}, fmt.Errorf("无效的ruleset: %s", value)
+	}
+	ruleset := TemplateRuleset{Group: strings.TrimSpace(group), Type: "surge", Interval: 86400}
+	source = strings.TrimSpace(source)
+
+	if strings.HasPrefix(source, "[]") {
+		ruleset.Rule = strings.TrimSpace(strings.TrimPrefix(source, "[]"))
+		return ruleset, nil
+	}
+
+	// 末尾的数字为更新间隔
+	if idx := strings.LastIndex(source, ","); idx >= 0 {
+		if interval, err := strconv.Atoi(strings.TrimSpace(source[idx+1:])); err == nil {
+			ruleset.Interval = interval
+			source = strings.TrimSpace(source[:idx])
+		}
+	}
+	if prefix, rest, ok := strings.Cut(source, ":"); ok && !strings.HasPrefix(rest, "//") {
+		ruleset.Type, source = prefix, rest
+	}
+	if !isHTTPURL(source) {
+		return TemplateRuleset{}, fmt.Errorf("规则集仅支持http(s)链接: %s", source)
+	}
+	ruleset.URL = source
+	return ruleset, nil
+}
+
+func parseTemplateGroup(value string) (TemplateGroup, error) {
+	fields := strings.Split(value, "`")
+	if len(fields) < 3 {
+		return TemplateGroup{}, fmt.Errorf("无效的custom_proxy_group: %s", value)
+	}
+	group := TemplateGroup{Name: strings.TrimSpace(fields[0]), Type: strings.TrimSpace(fields[1])}
+	members := fields[2:]
+
+	switch group.Type {
+	case "select", "relay":
+	case "url-test", "fallback", "load-balance":
+		// 最后两个字段为测速地址和 间隔[,超时][,容差]
+		if len(members) < 3 {
+			return TemplateGroup{}, fmt.Errorf("代理组 %s 缺少测速地址或间隔", group.Name)
+		}
+		group.URL = strings.TrimSpace(members[len(members)-2])
+		timing := strings.Split(members[len(members)-1], ",")
+		group.Interval, _ = strconv.Atoi(strings.TrimSpace(timing[0]))
+		if len(timing) >= 3 {
+			group.Tolerance, _ = strconv.Atoi(strings.TrimSpace(timing[2]))
+		}
+		members = members[:len(members)-2]
+	default:
+		return TemplateGroup{}, fmt.Errorf("不支持的代理组类型: %s", group.Type)
+	}
+
+	for _, member := range members {
+		if member = strings.TrimSpace(member); member == "" {
+			continue
+		}
+		if strings.HasPrefix(member, "[]") {
+			group.Members = append(group.Members, TemplateMember{Group: strings.TrimPrefix(member, "[]")})
+			continue
+		}
+		pattern, err := regexp.Compile(member)
+		if err != nil {
+			log.Printf("代理组 %s 的正则 %s 不受支持，匹配节点时跳过: %v", group.Name, member, err)
+		}
+		group.Members = append(group.Members, TemplateMember{Pattern: member, regexp: pattern})
+	}
+	return group, nil
+}
+
+// 按模板生成代理组、规则及规则集。providerName不为空时节点来自该proxy-provider，
+// 正则成员转为代理组的filter（由客户端匹配），否则直接匹配proxies中的节点名称
+func (t *ConfigTemplate) build(proxies []ProxyConfig, providerName string) ([]ProxyGroup, []string, map[string]RuleProvider) {
+	var groups []ProxyGroup
+	for _, tg := range t.Groups {
+		group := ProxyGroup{
+			Name:      tg.Name,
+			Type:      tg.Type,
+			URL:       tg.URL,
+			Interval:  tg.Interval,
+			Tolerance: tg.Tolerance,
+		}
+		added := make(map[string]bool)
+		add := func(name string) {
+			if !added[name] {
+				added[name] = true
+				group.Proxies = append(group.Proxies, name)
+			}
+		}
+		var filters []string
+		for _, member := range tg.Members {
+			switch {
+			case member.Group != "":
+				add(member.Group)
+			case providerName != "":
+				filters = append(filters, member.Pattern)
+			case member.regexp != nil:
+				for _, proxy := range proxies {
+					if member.regexp.MatchString(proxy.Name) {
+						add(proxy.Name)
+					}
+				}
+			}
+		}
+		if len(filters) > 0 {
+			group.Use = []string{providerName}
+			// 多个正则之间用反引号分隔，与proxy-providers的filter写法相同
+			group.Filter = strings.Join(filters, "`")
+		}
+		// Clash不允许空的代理组
+		if len(group.Proxies) == 0 && len(group.Use) == 0 {
+			group.Proxies = []string{"DIRECT"}
+		}
+		groups = append(groups, group)
+	}
+
+	var rules []string
+	providers := make(map[string]RuleProvider)
+	for _, ruleset := range t.Rulesets {
+		if ruleset.Rule != "" {
+			rules = append(rules, templateRule(ruleset.Rule, ruleset.Group))
+			continue
+		}
+		behavior, format, ext := "classical", "text", "list"
+		switch ruleset.Type {
+		case "surge", "clash-classical-text":
+		case "clash-domain":
+			behavior, format, ext = "domain", "yaml", "yaml"
+		case "clash-ipcidr":
+			behavior, format, ext = "ipcidr", "yaml", "yaml"
+		case "clash-classic", "clash-classical":
+			format, ext = "yaml", "yaml"
+		default:
+			log.Printf("跳过不支持的规则集类型 %s: %s", ruleset.Type, ruleset.URL)
+			continue
+		}
+		name := templateProviderName(ruleset.URL, providers)
+		providers[name] = RuleProvider{
+			Type:     "http",
+			Behavior: behavior,
+			Format:   format,
+			URL:      ruleset.URL,
+			Path:     fmt.Sprintf("./ruleset/%s.%s", name, ext),
+			Interval: ruleset.Interval,
+		}
+		rules = append(rules, fmt.Sprintf("RULE-SET,%s,%s", name, ruleset.Group))
+	}
+	return groups, rules, providers
+}
+
+// 将模板中的单条规则转换为Clash规则：FINAL转为MATCH，no-resolve等参数放在策略之后
+func templateRule(rule, group string) string {
+	parts := strings.Split(rule, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	if strings.EqualFold(parts[0], "FINAL") || strings.EqualFold(parts[0], "MATCH") {
+		return "MATCH," + group
+	}
+	if last := parts[len(parts)-1]; len(parts) > 2 && last == "no-resolve" {
+		return strings.Join(parts[:len(parts)-1], ",") + "," + group + ",no-resolve"
+	}
+	return strings.Join(parts, ",") + "," + group
+}
+
+// 以规则集文件名作为rule-providers名称，重名时追加序号
+func templateProviderName(rawURL string, providers map[string]RuleProvider) string {
+	base := "ruleset"
+	if u, err := url.Parse(rawURL); err == nil {
+		if name := strings.TrimSuffix(path.Base(u.Path), path.Ext(u.Path)); name != "" && name != "." && name != "/" {
+			base = name
+		}
+	}
+	name := base
+	for n := 2; ; n++ {
+		if _, exists := providers[name]; !exists {
+			return name
+		}
+		name = fmt.Sprintf("%s_%d", base, n)
+	}
+}
+
+// 读取配置模板，结果按来源缓存；缓存过期后重新加载失败时继续使用旧的模板
+func cachedConfigTemplate(source string) (*ConfigTemplate, error) {
+	cached, ok := configTemplateCache.Load(source)
+	if ok && time.Now().Before(cached.(configTemplateCacheEntry).expires) {
+		return cached.(configTemplateCacheEntry).template, nil
+	}
+	tmpl, err := loadConfigTemplate(source)
+	if err != nil {
+		if ok {
+			log.Printf("重新加载配置模板 %s 失败，继续使用缓存: %v", source, err)
+			return cached.(configTemplateCacheEntry).template, nil
+		}
+		return nil, err
+	}
+	configTemplateCache.Store(source, configTemplateCacheEntry{template: tmpl, expires: time.Now().Add(configTemplateCacheTTL)})
+	return tmpl, nil
+}
+
+// 订阅使用的配置模板来源：优先使用订阅指定的模板，其次为CONFIG_TEMPLATE环境变量
+func configTemplateSource(options ProxyOptions) string {
+	if options.Template == "" {
+		return os.Getenv("CONFIG_TEMPLATE")
+	}
+	if isHTTPURL(options.Template) {
+		return options.Template
+	}
+	return filepath.Join(configTemplateDir, options.Template)
+}
+
+// 检查订阅指定的配置模板能否读取和解析，保存设置时调用
+func checkConfigTemplate(options ProxyOptions) error {
+	if options.Template == "" {
+		return nil
+	}
+	_, err := cachedConfigTemplate(configTemplateSource(options))
+	return err
+}
+
+// 读取订阅使用的配置模板，未指定模板时返回nil；模板不可用时返回nil及提示信息，
+// 调用方使用默认分组和规则，并将提示写入生成的配置
+func optionsConfigTemplate(options ProxyOptions) (*ConfigTemplate, string) {
+	source := configTemplateSource(options)
+	if source == "" {
+		return nil, ""
+	}
+	tmpl, err := cachedConfigTemplate(source)
+	if err != nil {
+		log.Printf("配置模板 %s 不可用，使用默认代理组和规则: %v", source, err)
+		return nil, fmt.Sprintf("配置模板 %s 不可用，已使用默认代理组和规则: %v", source, err)
+	}
+	return tmpl, ""
+}
+
+// 将提示信息作为注释放在生成的配置开头（YAML及Surge/Loon/QuanX配置均以#开头表示注释），
+// Surge的 #!MANAGED-CONFIG 必须位于第一行，提示放在其后
+func withConfigWarning(content, warning string) string {
+	if warning == "" {
+		return content
+	}
+	comment := "# 警告: " + strings.ReplaceAll(warning, "\n", " ") + "\n"
+	if strings.HasPrefix(content, "#!") {
+		if first, rest, ok := strings.Cut(content, "\n"); ok {
+			return first + "\n" + comment + rest
+		}
+	}
+	return comment + content
+}
+
+// 生成代理组、规则及规则集：有配置模板时按模板生成，否则使用默认分组和规则；
+// 地区分组只作用于默认分组。模板不可用时返回提示信息
+func buildProxyGroupsAndRules(proxies []ProxyConfig, options ProxyOptions) ([]ProxyGroup, []string, map[string]RuleProvider, string) {
+	tmpl, warning := optionsConfigTemplate(options)
+	if tmpl != nil {
+		groups, rules, providers := tmpl.build(proxies, "")
+		return groups, rules, providers, ""
+	}
+
+	var proxyNames []string
+	for _, proxy := range proxies {
+		proxyNames = append(proxyNames, proxy.Name)
+	}
+	groups := defaultProxyGroups(proxyNames)
+	if options.RegionGroups != "" {
+		groups = addRegionProxyGroups(groups, proxies, options.RegionGroups)
+	}
+	return groups, defaultRules(), defaultRuleProviders(), warning
+}
+
+// 生成节点来自proxy-provider时的代理组、规则及规则集，代理组通过use引用providerName，
+// proxies为当前的节点，用于避免地区分组与节点重名
+func buildProviderGroupsAndRules(providerName string, proxies []ProxyConfig, options ProxyOptions) ([]ProxyGroup, []string, map[string]RuleProvider, string) {
+	tmpl, warning := optionsConfigTemplate(options)
+	if tmpl != nil {
+		groups, rules, providers := tmpl.build(nil, providerName)
+		return groups, rules, providers, ""
+	}
+
+	groups := defaultProxyGroups(nil)
+	for i := range groups {
+		switch groups[i].Name {
+		case "🔰 节点选择", "♻️ 自动选择":
+			groups[i].Use = []string{providerName}
+		}
+	}
+	if options.RegionGroups != "" {
+		groups = addProviderRegionProxyGroups(groups, proxies, options.RegionGroups, providerName)
+	}
+	return groups, defaultRules(), defaultRuleProviders(), warning
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ACL4SSR_Online_Full.ini 节选，另加一条带no-resolve的规则、一个clash-domain规则集
+// 和一个使用前瞻断言的代理组（Go正则不支持）
+const acl4ssrTemplate = `[custom]
+;不要随意改变关键字，否则会导致出错
+;acl4SSR规则-在线更新版
+
+ruleset=🎯 全球直连,https://raw.githubusercontent.com/ACL4SSR/ACL4SSR/master/Clash/LocalAreaNetwork.list
+ruleset=🛑 全球拦截,https://raw.githubusercontent.com/ACL4SSR/ACL4SSR/master/Clash/BanAD.list
+ruleset=📲 电报信息,https://raw.githubusercontent.com/ACL4SSR/ACL4SSR/master/Clash/Telegram.list
+ruleset=🚀 节点选择,https://raw.githubusercontent.com/ACL4SSR/ACL4SSR/master/Clash/ProxyLite.list
+ruleset=🎯 全球直连,clash-domain:https://cdn.jsdelivr.net/gh/Loyalsoldier/clash-rules@release/direct.txt,3600
+ruleset=🎯 全球直连,[]GEOIP,LAN,no-resolve
+ruleset=🎯 全球直连,[]GEOIP,CN
+ruleset=🐟 漏网之鱼,[]FINAL
+
+custom_proxy_group=🚀 节点选择` + "`select`[]♻️ 自动选择`[]🇭🇰 香港节点`[]DIRECT`.*" + `
+custom_proxy_group=♻️ 自动选择` + "`url-test`.*`http://www.gstatic.com/generate_204`300,,50" + `
+custom_proxy_group=📲 电报信息` + "`select`[]🚀 节点选择`[]🇸🇬 狮城节点`[]DIRECT" + `
+custom_proxy_group=🎯 全球直连` + "`select`[]DIRECT`[]🚀 节点选择`[]♻️ 自动选择" + `
+custom_proxy_group=🛑 全球拦截` + "`select`[]REJECT`[]DIRECT" + `
+custom_proxy_group=🐟 漏网之鱼` + "`select`[]🚀 节点选择`[]🎯 全球直连`[]♻️ 自动选择`.*" + `
+custom_proxy_group=🇭🇰 香港节点` + "`url-test`(港|HK|Hong Kong)`http://www.gstatic.com/generate_204`300,,50" + `
+custom_proxy_group=🇸🇬 狮城节点` + "`url-test`(新加坡|坡|狮城|SG|Singapore)`http://www.gstatic.com/generate_204`300,,50" + `
+custom_proxy_group=🌐 非港节点` + "`select`^(?!.*(港|HK)).*$" + `
+
+enable_rule_generator=true
+overwrite_original_rules=true
+`
+
+func findProxyGroup(groups []ProxyGroup, name string) *ProxyGroup {
+	for i := range groups {
+		if groups[i].Name == name {
+			return &groups[i]
+		}
+	}
+	return nil
+}
+
+func TestConfigTemplateACL4SSR(t *testing.T) {
+	tmpl, err := parseConfigTemplate(acl4ssrTemplate)
+	if err != nil {
+		t.Fatalf("parseConfigTemplate() error: %v", err)
+	}
+	proxies := []ProxyConfig{{Name: "香港 01"}, {Name: "HK 02"}, {Name: "新加坡 01"}, {Name: "美国 01"}}
+	groups, rules, providers := tmpl.build(proxies, "")
+
+	if len(providers) != 5 {
+		t.Errorf("rule-providers = %d, want 5", len(providers))
+	}
+	lan := providers["LocalAreaNetwork"]
+	if lan.Behavior != "classical" || lan.Format != "text" || lan.Interval != 86400 ||
+		lan.clientListURL() != "https://raw.githubusercontent.com/ACL4SSR/ACL4SSR/master/Clash/LocalAreaNetwork.list" {
+		t.Errorf("LocalAreaNetwork provider = %+v", lan)
+	}
+	direct := providers["direct"]
+	if direct.Behavior != "domain" || direct.Format != "yaml" || direct.Interval != 3600 || direct.clientListURL() != "" {
+		t.Errorf("direct provider = %+v", direct)
+	}
+
+	wantMembers := map[string][]string{
+		"🚀 节点选择":  {"♻️ 自动选择", "🇭🇰 香港节点", "DIRECT", "香港 01", "HK 02", "新加坡 01", "美国 01"},
+		"🇭🇰 香港节点": {"香港 01", "HK 02"},
+		"🇸🇬 狮城节点": {"新加坡 01"},
+		"🛑 全球拦截":  {"REJECT", "DIRECT"},
+		// 前瞻断言无法在Go中匹配，跳过后代理组为空
+		"🌐 非港节点": {"DIRECT"},
+	}
+	for name, want := range wantMembers {
+		group := findProxyGroup(groups, name)
+		if group == nil {
+			t.Errorf("proxy group %s not found", name)
+			continue
+		}
+		if !reflect.DeepEqual(group.Proxies, want) {
+			t.Errorf("proxy group %s = %v, want %v", name, group.Proxies, want)
+		}
+	}
+	if auto := findProxyGroup(groups, "♻️ 自动选择"); auto == nil || auto.Interval != 300 || auto.Tolerance != 50 {
+		t.Errorf("♻️ 自动选择 = %+v, want interval 300 tolerance 50", auto)
+	}
+
+	wantRules := []string{
+		"RULE-SET,LocalAreaNetwork,🎯 全球直连",
+		"RULE-SET,BanAD,🛑 全球拦截",
+		"RULE-SET,Telegram,📲 电报信息",
+		"RULE-SET,ProxyLite,🚀 节点选择",
+		"RULE-SET,direct,🎯 全球直连",
+		"GEOIP,LAN,🎯 全球直连,no-resolve",
+		"GEOIP,CN,🎯 全球直连",
+		"MATCH,🐟 漏网之鱼",
+	}
+	if !reflect.DeepEqual(rules, wantRules) {
+		t.Errorf("rules = %v, want %v", rules, wantRules)
+	}
+}
+
+func TestConfigTemplateProviderGroups(t *testing.T) {
+	tmpl, err := parseConfigTemplate(acl4ssrTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups, _, _ := tmpl.build(nil, "subscription-1")
+
+	// 正则成员交给客户端按filter匹配，Go不支持的正则也原样保留
+	tests := []struct {
+		name    string
+		proxies []string
+		filter  string
+	}{
+		{"🚀 节点选择", []string{"♻️ 自动选择", "🇭🇰 香港节点", "DIRECT"}, ".*"},
+		{"🇭🇰 香港节点", nil, "(港|HK|Hong Kong)"},
+		{"🌐 非港节点", nil, "^(?!.*(港|HK)).*$"},
+		{"🛑 全球拦截", []string{"REJECT", "DIRECT"}, ""},
+	}
+	for _, tt := range tests {
+		group := findProxyGroup(groups, tt.name)
+		if group == nil {
+			t.Errorf("proxy group %s not found", tt.name)
+			continue
+		}
+		wantUse := []string{"subscription-1"}
+		if tt.filter == "" {
+			wantUse = nil
+		}
+		if !reflect.DeepEqual(group.Proxies, tt.proxies) || !reflect.DeepEqual(group.Use, wantUse) || group.Filter != tt.filter {
+			t.Errorf("proxy group %s = proxies %v use %v filter %q, want %v %v %q",
+				tt.name, group.Proxies, group.Use, group.Filter, tt.proxies, wantUse, tt.filter)
+		}
+	}
+}
+
+func TestConfigTemplateCacheAndFallback(t *testing.T) {
+	var requests, failing atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if failing.Load() != 0 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(acl4ssrTemplate))
+	}))
+	defer server.Close()
+
+	options := ProxyOptions{Template: server.URL + "/ACL4SSR_Online_Full.ini"}
+	proxies := []ProxyConfig{{Name: "香港 01"}}
+	for i := 0; i < 2; i++ {
+		if groups, _, _, warning := buildProxyGroupsAndRules(proxies, options); findProxyGroup(groups, "🇭🇰 香港节点") == nil || warning != "" {
+			t.Fatalf("template groups not used: %v, warning %q", groups, warning)
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("template fetched %d times, want 1", n)
+	}
+
+	// 缓存过期后重新加载失败，继续使用旧的模板
+	failing.Store(1)
+	cached, _ := configTemplateCache.Load(options.Template)
+	entry := cached.(configTemplateCacheEntry)
+	entry.expires = time.Now().Add(-time.Second)
+	configTemplateCache.Store(options.Template, entry)
+	if groups, _, _, warning := buildProxyGroupsAndRules(proxies, options); findProxyGroup(groups, "🇭🇰 香港节点") == nil || warning != "" {
+		t.Errorf("stale template not used after reload failure: %v, warning %q", groups, warning)
+	}
+
+	// 从未加载成功的模板回退到默认分组和规则，并返回提示信息
+	options.Template = server.URL + "/missing.ini"
+	groups, rules, _, warning := buildProxyGroupsAndRules(proxies, options)
+	if findProxyGroup(groups, "🔰 节点选择") == nil || !strings.HasPrefix(rules[len(rules)-1], "MATCH,") {
+		t.Errorf("default groups not used when template is unavailable: %v", groups)
+	}
+	if !strings.Contains(warning, options.Template) {
+		t.Errorf("warning = %q, want it to name the unavailable template", warning)
+	}
+	if err := validateProxyOptions(options); err == nil {
+		t.Error("validateProxyOptions accepted a template that cannot be loaded")
+	}
+}
+
+func TestWithConfigWarning(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"proxies: []\n", "# 警告: 模板不可用\nproxies: []\n"},
+		{"#!MANAGED-CONFIG https://example.com interval=86400\n[General]\n",
+			"#!MANAGED-CONFIG https://example.com interval=86400\n# 警告: 模板不可用\n[General]\n"},
+	}
+	for _, tt := range tests {
+		if got := withConfigWarning(tt.content, "模板不可用"); got != tt.want {
+			t.Errorf("withConfigWarning(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+	if got := withConfigWarning("proxies: []\n", ""); got != "proxies: []\n" {
+		t.Errorf("withConfigWarning without warning = %q", got)
+	}
+}
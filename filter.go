@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 节点过滤条件，空字段表示不过滤
+type ProxyFilter struct {
+	Include string   `json:"include,omitempty"` // 节点名称需匹配的正则
+	Exclude string   `json:"exclude,omitempty"` // 节点名称匹配时剔除的正则
+	Types   []string `json:"types,omitempty"`   // 保留的协议类型，如 ss、vmess
+	Ports   []string `json:"ports,omitempty"`   // 保留的端口或端口范围，如 443、8000-9000
+}
+
+// 从URL查询参数读取过滤条件：include、exclude、type、port（逗号分隔）
+func proxyFilterFromQuery(query map[string][]string) ProxyFilter {
+	get := func(key string) string {
+		if values := query[key]; len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+	filter := ProxyFilter{
+		Include: get("include"),
+		Exclude: get("exclude"),
+	}
+	for _, proxyType := range strings.Split(get("type"), ",") {
+		if proxyType = strings.TrimSpace(proxyType); proxyType != "" {
+			filter.Types = append(filter.Types, strings.ToLower(proxyType))
+		}
+	}
+	for _, port := range strings.Split(get("port"), ",") {
+		if port = strings.TrimSpace(port); port != "" {
+			filter.Ports = append(filter.Ports, port)
+		}
+	}
+	return filter
+}
+
+// 是否未设置任何过滤条件
+func (f ProxyFilter) isEmpty() bool {
+	return f.Include == "" && f.Exclude == "" && len(f.Types) == 0 && len(f.Ports) == 0
+}
+
+// 过滤条件的JSON表示，用于保存到数据库和计算配置哈希，未设置时为空字符串
+func (f ProxyFilter) String() string {
+	if f.isEmpty() {
+		return ""
+	}
+	data, _ := json.Marshal(f)
+	return string(data)
+}
+
+// 读取数据库中保存的过滤条件
+func parseProxyFilter(data string) (ProxyFilter, error) {
+	var filter ProxyFilter
+	if data == "" {
+		return filter, nil
+	}
+	err := json.Unmarshal([]byte(data), &filter)
+	return filter, err
+}
+
+// 检查过滤条件中的正则和端口范围是否有效
+func validateProxyFilter(filter ProxyFilter) error {
+	_, err := filterProxies(nil, filter)
+	return err
+}
+
+// 解析端口或端口范围（如 443、8000-9000）
+func parsePortRange(value string) (int, int, error) {
+	startStr, endStr, isRange := strings.Cut(strings.TrimSpace(value), "-")
+	start, err := strconv.Atoi(strings.TrimSpace(startStr))
+	if err != nil {
+		return 0, 0, fmt.Errorf("无效的端口: %s", value)
+	}
+	end := start
+	if isRange {
+		if end, err = strconv.Atoi(strings.TrimSpace(endStr)); err != nil {
+			return 0, 0, fmt.Errorf("无效的端口: %s", value)
+		}
+	}
+	if start < 1 || end > 65535 || start > end {
+		return 0, 0, fmt.Errorf("无效的端口范围: %s", value)
+	}
+	return start, end, nil
+}
+
+// 按过滤条件筛选节点
+func filterProxies(proxies []ProxyConfig, filter ProxyFilter) ([]ProxyConfig, error) {
+	var include, exclude *regexp.Regexp
+	var err error
+	if filter.Include != "" {
+		if include, err = regexp.Compile(filter.Include); err != nil {
+			return nil, fmt.Errorf("无效的包含正则: %v", err)
+		}
+	}
+	if filter.Exclude != "" {
+		if exclude, err = regexp.Compile(filter.Exclude); err != nil {
+			return nil, fmt.Errorf("无效的排除正则: %v", err)
+		}
+	}
+	types := make(map[string]bool)
+	for _, proxyType := range filter.Types {
+		types[strings.ToLower(proxyType)] = true
+	}
+	var portRanges [][2]int
+	for _, port := range filter.Ports {
+		start, end, err := parsePortRange(port)
+		if err != nil {
+			return nil, err
+		}
+		portRanges = append(portRanges, [2]int{start, end})
+	}
+
+	var result []ProxyConfig
+	for _, proxy := range proxies {
+		if include != nil && !include.MatchString(proxy.Name) {
+			continue
+		}
+		if exclude != nil && exclude.MatchString(proxy.Name) {
+			continue
+		}
+		if len(types) > 0 && !types[proxy.Type] {
+			continue
+		}
+		if len(portRanges) > 0 && !portInRanges(proxy.Port, portRanges) {
+			continue
+		}
+		result = append(result, proxy)
+	}
+	return result, nil
+}
+
+// 判断端口是否落在任一范围内
+func portInRanges(port int, ranges [][2]int) bool {
+	for _, r := range ranges {
+		if port >= r[0] && port <= r[1] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// 离线GeoIP数据库路径，可通过环境变量 GEOIP_DATABASE 指定，
+// 未指定时依次尝试工作目录下的以下文件
+var geoIPDatabaseFiles = []string{"GeoLite2-Country.mmdb", "Country.mmdb"}
+
+const (
+	// 一次查询中解析所有服务器域名的总时限及并发数
+	geoIPLookupTimeout     = 5 * time.Second
+	geoIPLookupConcurrency = 16
+	// 查询结果的缓存时间；域名解析失败或数据库中没有记录时缓存较短时间，
+	// 避免每次请求都重新解析失效的域名
+	geoIPCacheTTL         = time.Hour
+	geoIPNegativeCacheTTL = 5 * time.Minute
+)
+
+var (
+	geoIPOnce   sync.Once
+	geoIPReader *maxminddb.Reader
+	geoIPCache  sync.Map // 服务器地址 -> geoIPCacheEntry
+)
+
+type geoIPCacheEntry struct {
+	code    string // 查询失败时为空
+	expires time.Time
+}
+
+// GeoIP数据库中用到的字段
+type geoIPRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	RegisteredCountry struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"registered_country"`
+}
+
+// 加载GeoIP数据库，只在第一次查询时执行
+func loadGeoIPDatabase() *maxminddb.Reader {
+	geoIPOnce.Do(func() {
+		files := geoIPDatabaseFiles
+		if path := os.Getenv("GEOIP_DATABASE"); path != "" {
+			files = []string{path}
+		}
+		for _, file := range files {
+			if _, err := os.Stat(file); err != nil {
+				continue
+			}
+			reader, err := maxminddb.Open(file)
+			if err != nil {
+				log.Printf("加载GeoIP数据库 %s 失败: %v", file, err)
+				continue
+			}
+			log.Printf("已加载GeoIP数据库: %s", file)
+			geoIPReader = reader
+			return
+		}
+	})
+	return geoIPReader
+}
+
+// 批量查询服务器所在国家代码，返回 服务器地址 -> 国家代码；
+// 域名并发解析且共用一个总时限，未配置数据库时返回空结果
+func lookupServerCountries(servers []string) map[string]string {
+	result := make(map[string]string)
+	reader := loadGeoIPDatabase()
+	if reader == nil {
+		return result
+	}
+
+	var pending []string
+	seen := make(map[string]bool)
+	now := time.Now()
+	for _, server := range servers {
+		if server == "" || seen[server] {
+			continue
+		}
+		seen[server] = true
+		if cached, ok := geoIPCache.Load(server); ok && now.Before(cached.(geoIPCacheEntry).expires) {
+			if code := cached.(geoIPCacheEntry).code; code != "" {
+				result[server] = code
+			}
+			continue
+		}
+		pending = append(pending, server)
+	}
+	if len(pending) == 0 {
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), geoIPLookupTimeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, geoIPLookupConcurrency)
+	for _, server := range pending {
+		wg.Add(1)
+		go func(server string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			code, ok := lookupServerCountry(ctx, reader, server)
+			if !ok || code == "" {
+				// 超出本次查询总时限的不算作失败，下次请求重新查询
+				if ctx.Err() == nil {
+					geoIPCache.Store(server, geoIPCacheEntry{expires: time.Now().Add(geoIPNegativeCacheTTL)})
+				}
+				return
+			}
+			geoIPCache.Store(server, geoIPCacheEntry{code: code, expires: time.Now().Add(geoIPCacheTTL)})
+			mu.Lock()
+			result[server] = code
+			mu.Unlock()
+		}(server)
+	}
+	wg.Wait()
+	return result
+}
+
+// 查询单个服务器所在国家代码，服务器为域名时先解析IP；解析失败时ok为false，
+// 数据库中没有记录时返回空代码
+func lookupServerCountry(ctx context.Context, reader *maxminddb.Reader, server string) (string, bool) {
+	ip := net.ParseIP(server)
+	if ip == nil {
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, server)
+		if err != nil || len(addrs) == 0 {
+			return "", false
+		}
+		ip = addrs[0].IP
+	}
+
+	var record geoIPRecord
+	if err := reader.Lookup(ip, &record); err != nil {
+		log.Printf("查询 %s 的GeoIP信息失败: %v", server, err)
+		return "", false
+	}
+	code := record.Country.ISOCode
+	if code == "" {
+		code = record.RegisteredCountry.ISOCode
+	}
+	return strings.ToUpper(code), true
+}
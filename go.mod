@@ -4,6 +4,7 @@ go 1.21
 
 require (
 	github.com/glebarez/go-sqlite v1.22.0
+	github.com/oschwald/maxminddb-golang v1.13.1
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -12,7 +13,7 @@ require (
 	github.com/google/uuid v1.5.0 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
-	golang.org/x/sys v0.15.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
 	modernc.org/libc v1.37.6 // indirect
 	modernc.org/mathutil v1.6.0 // indirect
 	modernc.org/memory v1.7.2 // indirect
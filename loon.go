@@ -0,0 +1,254 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Loon支持的节点类型（Clash类型 -> Loon类型），不在表中的节点会被跳过
+var loonProxyTypes = map[string]string{
+	"ss":        "Shadowsocks",
+	"ssr":       "ShadowsocksR",
+	"vmess":     "vmess",
+	"vless":     "VLESS",
+	"trojan":    "trojan",
+	"hysteria2": "Hysteria2",
+	"wireguard": "WireGuard",
+	"socks5":    "socks5",
+	"http":      "http",
+}
+
+// Loon支持的规则类型（Clash类型 -> Loon类型）
+var loonRuleTypes = map[string]string{
+	"DOMAIN":         "DOMAIN",
+	"DOMAIN-SUFFIX":  "DOMAIN-SUFFIX",
+	"DOMAIN-KEYWORD": "DOMAIN-KEYWORD",
+	"IP-CIDR":        "IP-CIDR",
+	"IP-CIDR6":       "IP-CIDR6",
+	"GEOIP":          "GEOIP",
+	"DST-PORT":       "DEST-PORT",
+	"MATCH":          "FINAL",
+}
+
+// 生成Loon配置，代理组与规则沿用generateFullClashConfig的分组模型
+func generateLoonConfig(proxies []ProxyConfig, groups []ProxyGroup, rules []string, ruleProviders map[string]RuleProvider) (string, int, error) {
+	log.Printf("开始生成Loon配置，节点数量: %d", len(proxies))
+
+	proxyLines, nodeNames := buildClientProxyLines("Loon", proxies, loonProxyTypes, loonProxyLine)
+	if len(proxyLines) == 0 {
+		return "", 0, fmt.Errorf("未找到任何Loon支持的代理节点")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("[General]\n")
+	sb.WriteString("ipv6 = false\n")
+	sb.WriteString("dns-server = 223.5.5.5, 119.29.29.29\n")
+	sb.WriteString("skip-proxy = 127.0.0.1, 192.168.0.0/16, 10.0.0.0/8, 172.16.0.0/12, localhost, *.local\n")
+	sb.WriteString("proxy-test-url = http://www.gstatic.com/generate_204\n")
+
+	sb.WriteString("\n[Proxy]\n")
+	for _, line := range proxyLines {
+		sb.WriteString(line + "\n")
+	}
+
+	sb.WriteString("\n[Proxy Group]\n")
+	for _, group := range filterClientGroups(groups, nodeNames) {
+		fields := append([]string{surgeGroupTypes[group.Type]}, group.Proxies...)
+		if group.URL != "" {
+			fields = append(fields, "url="+group.URL)
+		}
+		if group.Interval > 0 {
+			fields = append(fields, "interval="+strconv.Itoa(group.Interval))
+		}
+		sb.WriteString(fmt.Sprintf("%s = %s\n", group.Name, strings.Join(fields, ",")))
+	}
+
+	// 规则集写入[Remote Rule]
+	clientRules, ruleSets := translateClientRules(rules, ruleProviders, loonRuleTypes, true, nil)
+	if len(ruleSets) > 0 {
+		sb.WriteString("\n[Remote Rule]\n")
+		for _, ruleSet := range ruleSets {
+			sb.WriteString(fmt.Sprintf("%s, policy=%s, tag=%s, enabled=true\n", ruleSet.URL, ruleSet.Policy, ruleSet.Name))
+		}
+	}
+
+	sb.WriteString("\n[Rule]\n")
+	for _, rule := range clientRules {
+		sb.WriteString(rule + "\n")
+	}
+
+	return sb.String(), len(proxyLines), nil
+}
+
+// 生成Loon节点行：名称 = 类型,服务器,端口,位置参数...,key=value
+func loonProxyLine(proxy ProxyConfig, name string) (string, error) {
+	fields := []string{loonProxyTypes[proxy.Type], proxy.Server, strconv.Itoa(proxy.Port)}
+	add := func(key, value string) {
+		if value != "" {
+			fields = append(fields, key+"="+quoteClientValue(value))
+		}
+	}
+	addTLS := func(serverName string) {
+		add("tls-name", serverName)
+		if proxy.SkipCertVerify {
+			add("skip-cert-verify", "true")
+		}
+	}
+	addTransport := func() error {
+		switch proxy.Network {
+		case "", "tcp":
+			add("transport", "tcp")
+		case "ws":
+			add("transport", "ws")
+			if proxy.WSOpts != nil {
+				add("path", proxy.WSOpts.Path)
+				add("host", proxy.WSOpts.Headers["Host"])
+			}
+		case "http":
+			add("transport", "http")
+			if proxy.HTTPOpts != nil {
+				if len(proxy.HTTPOpts.Path) > 0 {
+					add("path", proxy.HTTPOpts.Path[0])
+				}
+				if hosts := proxy.HTTPOpts.Headers["Host"]; len(hosts) > 0 {
+					add("host", hosts[0])
+				}
+			}
+		default:
+			return fmt.Errorf("Loon不支持的传输方式: %s", proxy.Network)
+		}
+		return nil
+	}
+
+	switch proxy.Type {
+	case "ss":
+		// Loon中加密方式与密码为位置参数，密码需要用引号包裹
+		fields = append(fields, proxy.Cipher, strconv.Quote(proxy.Password))
+		switch proxy.Plugin {
+		case "":
+		case "obfs":
+			add("obfs-name", getString(proxy.PluginOpts, "mode"))
+			add("obfs-host", getString(proxy.PluginOpts, "host"))
+		case "shadow-tls":
+			add("shadow-tls-password", getString(proxy.PluginOpts, "password"))
+			add("shadow-tls-sni", getString(proxy.PluginOpts, "host"))
+			if version := getInt(proxy.PluginOpts, "version"); version > 0 {
+				add("shadow-tls-version", strconv.Itoa(version))
+			}
+		default:
+			return "", fmt.Errorf("Loon不支持的SS插件: %s", proxy.Plugin)
+		}
+		if proxy.UDP {
+			add("udp", "true")
+		}
+	case "ssr":
+		fields = append(fields, proxy.Cipher, strconv.Quote(proxy.Password))
+		add("protocol", proxy.Protocol)
+		add("protocol-param", proxy.ProtocolParam)
+		add("obfs", proxy.Obfs)
+		add("obfs-param", proxy.ObfsParam)
+	case "vmess":
+		fields = append(fields, firstNonEmpty(proxy.Cipher, "auto"), strconv.Quote(proxy.UUID))
+		if err := addTransport(); err != nil {
+			return "", err
+		}
+		add("alterId", strconv.Itoa(proxy.AlterID))
+		if proxy.TLS {
+			add("over-tls", "true")
+			addTLS(proxy.ServerName)
+		}
+	case "vless":
+		fields = append(fields, strconv.Quote(proxy.UUID))
+		if err := addTransport(); err != nil {
+			return "", err
+		}
+		add("flow", proxy.Flow)
+		if proxy.TLS {
+			add("over-tls", "true")
+			addTLS(proxy.ServerName)
+		}
+		if proxy.RealityOpts != nil {
+			add("public-key", proxy.RealityOpts.PublicKey)
+			add("short-id", proxy.RealityOpts.ShortID)
+		}
+	case "trojan":
+		fields = append(fields, strconv.Quote(proxy.Password))
+		if proxy.Network == "ws" {
+			if err := addTransport(); err != nil {
+				return "", err
+			}
+		} else if proxy.Network != "" && proxy.Network != "tcp" {
+			return "", fmt.Errorf("Loon不支持的传输方式: %s", proxy.Network)
+		}
+		addTLS(proxy.SNI)
+	case "hysteria2":
+		if proxy.Obfs != "" && proxy.Obfs != "salamander" {
+			return "", fmt.Errorf("Loon不支持的Hysteria2混淆: %s", proxy.Obfs)
+		}
+		fields = append(fields, strconv.Quote(proxy.Password))
+		addTLS(proxy.SNI)
+		add("download-bandwidth", bandwidthMbps(proxy.Down))
+		add("salamander-password", proxy.ObfsPassword)
+	case "wireguard":
+		// Loon的WireGuard节点没有服务器和端口位置参数，对端信息写在peers中
+		fields = []string{loonProxyTypes[proxy.Type]}
+		add("interface-ip", proxy.IP)
+		add("interface-ipV6", proxy.IPv6)
+		add("private-key", proxy.PrivateKey)
+		if proxy.MTU > 0 {
+			add("mtu", strconv.Itoa(proxy.MTU))
+		}
+		peers := proxy.Peers
+		if len(peers) == 0 {
+			peers = []WireGuardPeer{{
+				Server:       proxy.Server,
+				Port:         proxy.Port,
+				PublicKey:    proxy.PublicKey,
+				PreSharedKey: proxy.PreSharedKey,
+				Reserved:     proxy.Reserved,
+			}}
+		}
+		var peerLines []string
+		for _, peer := range peers {
+			allowedIPs := peer.AllowedIPs
+			if len(allowedIPs) == 0 {
+				allowedIPs = []string{"0.0.0.0/0", "::/0"}
+			}
+			peerFields := []string{
+				"public-key=" + peer.PublicKey,
+				"allowed-ips=" + strconv.Quote(strings.Join(allowedIPs, ",")),
+				"endpoint=" + net.JoinHostPort(peer.Server, strconv.Itoa(peer.Port)),
+			}
+			if peer.PreSharedKey != "" {
+				peerFields = append(peerFields, "preshared-key="+peer.PreSharedKey)
+			}
+			if len(peer.Reserved) > 0 {
+				var reserved []string
+				for _, b := range peer.Reserved {
+					reserved = append(reserved, strconv.Itoa(b))
+				}
+				peerFields = append(peerFields, "reserved=["+strings.Join(reserved, ",")+"]")
+			}
+			peerLines = append(peerLines, "{"+strings.Join(peerFields, ",")+"}")
+		}
+		fields = append(fields, "peers=["+strings.Join(peerLines, ",")+"]")
+	case "socks5", "http":
+		if proxy.Type == "http" && proxy.TLS {
+			fields[0] = "https"
+		}
+		if proxy.Username != "" {
+			fields = append(fields, proxy.Username, strconv.Quote(proxy.Password))
+		}
+		if proxy.Type == "socks5" && proxy.TLS {
+			add("over-tls", "true")
+		}
+		if proxy.TLS {
+			addTLS(proxy.SNI)
+		}
+	}
+
+	return fmt.Sprintf("%s = %s", name, strings.Join(fields, ",")), nil
+}
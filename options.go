@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// 节点处理及分组设置，随订阅保存；嵌入到请求和订阅结构中，JSON字段保持平铺
+type ProxyOptions struct {
+	Filter       ProxyFilter  `json:"filter"`                  // 节点过滤条件
+	RenameRules  []RenameRule `json:"rename_rules,omitempty"`  // 节点重命名规则，过滤后按顺序应用
+	FlagEmoji    bool         `json:"flag_emoji,omitempty"`    // 是否为节点名称添加地区旗帜前缀
+	RegionGroups string       `json:"region_groups,omitempty"` // 地区分组类型（url-test、fallback），为空时不生成地区分组
+	Template     string       `json:"template,omitempty"`      // 外部配置模板（http(s)链接或templates目录下的文件名），为空时使用默认分组和规则
+}
+
+// 是否未设置任何节点处理选项
+func (o ProxyOptions) isEmpty() bool {
+	return o.Filter.isEmpty() && len(o.RenameRules) == 0 && !o.FlagEmoji && o.RegionGroups == "" && o.Template == ""
+}
+
+// 是否有设置需要用到节点地区
+func (o ProxyOptions) needsRegions() bool {
+	if o.FlagEmoji || o.RegionGroups != "" {
+		return true
+	}
+	for _, rule := range o.RenameRules {
+		if strings.Contains(rule.Template, "{region}") || strings.Contains(rule.Template, "{flag}") {
+			return true
+		}
+	}
+	return false
+}
+
+// 检查过滤条件、重命名规则、地区分组类型和配置模板是否有效，指定了配置模板时读取并解析模板
+func validateProxyOptions(options ProxyOptions) error {
+	if err := validateProxyFilter(options.Filter); err != nil {
+		return fmt.Errorf("节点过滤条件错误: %v", err)
+	}
+	if err := validateRenameRules(options.RenameRules); err != nil {
+		return fmt.Errorf("节点重命名规则错误: %v", err)
+	}
+	if options.RegionGroups != "" && !regionGroupTypes[options.RegionGroups] {
+		return fmt.Errorf("不支持的地区分组类型: %s", options.RegionGroups)
+	}
+	if err := validateTemplateSource(options.Template); err != nil {
+		return fmt.Errorf("无效的配置模板: %v", err)
+	}
+	// 配置模板自带代理组，地区分组只作用于默认分组
+	if options.RegionGroups != "" && (options.Template != "" || os.Getenv("CONFIG_TEMPLATE") != "") {
+		return fmt.Errorf("使用配置模板时不支持地区分组")
+	}
+	if err := checkConfigTemplate(options); err != nil {
+		return fmt.Errorf("无法使用配置模板: %v", err)
+	}
+	return nil
+}
+
+// 节点处理流程：过滤 -> 识别地区（按需） -> 重命名 -> 添加旗帜前缀 -> 名称去重
+func processProxies(proxies []ProxyConfig, options ProxyOptions) ([]ProxyConfig, error) {
+	processed, err := filterProxies(proxies, options.Filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(processed) < len(proxies) {
+		log.Printf("过滤条件排除了 %d 个节点", len(proxies)-len(processed))
+	}
+
+	// 地区识别可能需要查询GeoIP，只在旗帜、地区分组或重命名模板用到地区时执行
+	if options.needsRegions() {
+		detectProxyRegions(processed)
+	}
+
+	if processed, err = renameProxies(processed, options.RenameRules); err != nil {
+		return nil, err
+	}
+
+	if options.FlagEmoji {
+		for i := range processed {
+			if region, ok := regionByCode(processed[i].region); ok && !strings.HasPrefix(processed[i].Name, region.Flag) {
+				processed[i].Name = region.Flag + " " + processed[i].Name
+			}
+		}
+	}
+
+	return uniqueProxyNames(processed), nil
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Clash proxy-providers条目
+type ProxyProvider struct {
+	Type        string              `yaml:"type"`
+	URL         string              `yaml:"url"`
+	Interval    int                 `yaml:"interval,omitempty"`
+	Path        string              `yaml:"path,omitempty"`
+	HealthCheck ProviderHealthCheck `yaml:"health-check"`
+}
+
+// proxy-providers健康检查配置
+type ProviderHealthCheck struct {
+	Enable   bool   `yaml:"enable"`
+	URL      string `yaml:"url"`
+	Interval int    `yaml:"interval"`
+	Lazy     bool   `yaml:"lazy,omitempty"`
+}
+
+// Clash rule-providers条目
+type RuleProvider struct {
+	Type     string `yaml:"type"`
+	Behavior string `yaml:"behavior"`
+	Format   string `yaml:"format,omitempty"` // yaml（默认）或text
+	URL      string `yaml:"url"`
+	Path     string `yaml:"path,omitempty"`
+	Interval int    `yaml:"interval,omitempty"`
+
+	// Surge格式（classical文本）的同内容规则列表，供Surge/Loon/QuanX使用
+	listURL string
+}
+
+// 客户端可用的规则列表链接：classical文本格式的规则集可以直接使用，其余格式需提供listURL
+func (p RuleProvider) clientListURL() string {
+	if p.listURL != "" {
+		return p.listURL
+	}
+	if p.Behavior == "classical" && p.Format == "text" {
+		return p.URL
+	}
+	return ""
+}
+
+// 源配置中的proxy-providers条目，仅读取节点来源及过滤、覆写选项
+type SourceProxyProvider struct {
+	Type          string      `yaml:"type"`
+	URL           string      `yaml:"url"`
+	Filter        string      `yaml:"filter"`
+	ExcludeFilter string      `yaml:"exclude-filter"`
+	ExcludeType   string      `yaml:"exclude-type"`
+	Override      yaml.Node   `yaml:"override"`
+	Payload       []yaml.Node `yaml:"payload"`
+}
+
+// 展开源配置中的proxy-providers：远程provider通过downloadConfigFromURL下载，
+// inline provider读取payload，按provider名称排序后依次合并
+func resolveProxyProviders(providers map[string]SourceProxyProvider) []ProxyConfig {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var proxies []ProxyConfig
+	for _, name := range names {
+		provided, err := loadProxyProvider(providers[name])
+		if err != nil {
+			log.Printf("跳过proxy-provider %s: %v", name, err)
+			continue
+		}
+		log.Printf("从proxy-provider %s 中提取到 %d 个代理节点", name, len(provided))
+		proxies = append(proxies, provided...)
+	}
+	return proxies
+}
+
+// 统计原样使用的Clash配置中的节点数量：只计算内联的proxies和inline provider的节点，
+// 不下载远程provider，返回值remote为未计入的远程provider数量
+func countClashConfigProxies(content string) (count, remote int, err error) {
+	var clashConfig ClashConfig
+	if err := yaml.Unmarshal([]byte(content), &clashConfig); err != nil {
+		return 0, 0, err
+	}
+	count = len(clashConfig.Proxies)
+	for name, provider := range clashConfig.ProxyProviders {
+		if !isInlineProxyProvider(provider) {
+			remote++
+			continue
+		}
+		provided, err := loadProxyProvider(provider)
+		if err != nil {
+			log.Printf("跳过proxy-provider %s: %v", name, err)
+			continue
+		}
+		count += len(provided)
+	}
+	return count, remote, nil
+}
+
+// inline provider的节点直接写在payload中，无需下载
+func isInlineProxyProvider(provider SourceProxyProvider) bool {
+	return provider.Type == "inline" || (provider.URL == "" && len(provider.Payload) > 0)
+}
+
+// 读取单个provider的节点，并应用filter、exclude-filter、exclude-type和override
+func loadProxyProvider(provider SourceProxyProvider) ([]ProxyConfig, error) {
+	nodes := provider.Payload
+	switch {
+	case isInlineProxyProvider(provider):
+	case provider.URL != "":
+		content, err := downloadConfigFromURL(provider.URL)
+		if err != nil {
+			return nil, fmt.Errorf("下载失败: %v", err)
+		}
+		var downloaded struct {
+			Proxies []yaml.Node `yaml:"proxies"`
+		}
+		if err := yaml.Unmarshal([]byte(content), &downloaded); err != nil {
+			return nil, fmt.Errorf("解析节点列表失败: %v", err)
+		}
+		nodes = downloaded.Proxies
+	default:
+		return nil, fmt.Errorf("不支持的provider类型: %s", provider.Type)
+	}
+
+	filters, err := compileProviderFilters(provider.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("无效的filter: %v", err)
+	}
+	excludeFilters, err := compileProviderFilters(provider.ExcludeFilter)
+	if err != nil {
+		return nil, fmt.Errorf("无效的exclude-filter: %v", err)
+	}
+	excludeTypes := make(map[string]bool)
+	for _, proxyType := range strings.Split(provider.ExcludeType, "|") {
+		if proxyType = strings.TrimSpace(proxyType); proxyType != "" {
+			excludeTypes[strings.ToLower(proxyType)] = true
+		}
+	}
+	override, err := parseProviderOverride(&provider.Override)
+	if err != nil {
+		return nil, fmt.Errorf("无效的override: %v", err)
+	}
+
+	var proxies []ProxyConfig
+	for i := range nodes {
+		var proxy ProxyConfig
+		if err := nodes[i].Decode(&proxy); err != nil {
+			log.Printf("跳过无法解析的provider节点: %v", err)
+			continue
+		}
+		if excludeTypes[strings.ToLower(proxy.Type)] {
+			continue
+		}
+		if len(filters) > 0 && !matchAnyRegexp(filters, proxy.Name) {
+			continue
+		}
+		if matchAnyRegexp(excludeFilters, proxy.Name) {
+			continue
+		}
+		if override.fields != nil {
+			// 覆写在YAML节点上进行，未建模的字段也能原样输出
+			if err := applyYAMLOverride(&nodes[i], override.fields).Decode(&proxy); err != nil {
+				log.Printf("跳过覆写失败的provider节点 %s: %v", proxy.Name, err)
+				continue
+			}
+		}
+		for _, rename := range override.proxyName {
+			proxy.Name = rename.pattern.ReplaceAllString(proxy.Name, rename.target)
+		}
+		proxy.Name = override.prefix + proxy.Name + override.suffix
+		proxies = append(proxies, proxy)
+	}
+	return proxies, nil
+}
+
+// provider的override选项：名称相关选项单独处理，其余字段直接覆盖到节点上
+type providerOverride struct {
+	prefix    string
+	suffix    string
+	proxyName []providerRename
+	fields    *yaml.Node
+}
+
+// override.proxy-name中的一条重命名规则
+type providerRename struct {
+	pattern *regexp.Regexp
+	target  string
+}
+
+// 拆分override中的名称选项与字段覆写
+func parseProviderOverride(node *yaml.Node) (providerOverride, error) {
+	var override providerOverride
+	node = resolveYAMLAliases(node)
+	if node.Kind != yaml.MappingNode {
+		return override, nil
+	}
+
+	fields := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		switch key.Value {
+		case "additional-prefix":
+			override.prefix = value.Value
+		case "additional-suffix":
+			override.suffix = value.Value
+		case "proxy-name":
+			var renames []struct {
+				Pattern string `yaml:"pattern"`
+				Target  string `yaml:"target"`
+			}
+			if err := value.Decode(&renames); err != nil {
+				return override, err
+			}
+			for _, rename := range renames {
+				pattern, err := regexp.Compile(rename.Pattern)
+				if err != nil {
+					return override, err
+				}
+				override.proxyName = append(override.proxyName, providerRename{pattern: pattern, target: rename.Target})
+			}
+		case "name", "type":
+			// 名称和类型不允许被覆写
+		default:
+			fields.Content = append(fields.Content, key, value)
+		}
+	}
+	if len(fields.Content) > 0 {
+		override.fields = fields
+	}
+	return override, nil
+}
+
+// 返回覆写后的节点副本：已有的键替换取值，缺少的键追加到末尾
+func applyYAMLOverride(node, fields *yaml.Node) *yaml.Node {
+	node = resolveYAMLAliases(node)
+	if node.Kind != yaml.MappingNode {
+		return node
+	}
+	overridden := *node
+	overridden.Content = append([]*yaml.Node(nil), node.Content...)
+	for i := 0; i+1 < len(fields.Content); i += 2 {
+		key, value := fields.Content[i], fields.Content[i+1]
+		replaced := false
+		for j := 0; j+1 < len(overridden.Content); j += 2 {
+			if overridden.Content[j].Value == key.Value {
+				overridden.Content[j+1] = value
+				replaced = true
+			}
+		}
+		if !replaced {
+			overridden.Content = append(overridden.Content, key, value)
+		}
+	}
+	return &overridden
+}
+
+// 编译provider的filter表达式，多个正则之间用反引号分隔
+func compileProviderFilters(expr string) ([]*regexp.Regexp, error) {
+	var patterns []*regexp.Regexp
+	for _, part := range strings.Split(expr, "`") {
+		if part == "" {
+			continue
+		}
+		pattern, err := regexp.Compile(part)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// 判断名称是否匹配任一正则
+func matchAnyRegexp(patterns []*regexp.Regexp, name string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// 生成仅包含proxies列表的Clash配置，可直接作为proxy-providers的数据源
+func generateClashProxies(proxies []ProxyConfig) (string, int, error) {
+	var validProxies []ProxyConfig
+	for _, proxy := range proxies {
+		if err := validateProxy(proxy); err != nil {
+			log.Printf("跳过配置无效的节点 %s: %v", proxy.Name, err)
+			continue
+		}
+		validProxies = append(validProxies, proxy)
+	}
+	if len(validProxies) == 0 {
+		return "", 0, fmt.Errorf("未找到任何有效的代理配置")
+	}
+
+	yamlData, err := yaml.Marshal(&ClashConfig{Proxies: validProxies})
+	if err != nil {
+		return "", 0, fmt.Errorf("生成Clash节点列表失败: %v", err)
+	}
+	return string(yamlData), len(validProxies), nil
+}
+
+// 生成通过proxy-providers引用节点的完整Clash配置，节点不内联到配置中，
+// 代理组、规则及规则集由buildProviderGroupsAndRules生成
+func generateClashProviderConfig(providerName, providerURL string, groups []ProxyGroup, rules []string, ruleProviders map[string]RuleProvider) (string, error) {
+	fullConfig := FullClashConfig{
+		Port:               7890,
+		SocksPort:          7891,
+		MixedPort:          7892,
+		AllowLan:           false,
+		Mode:               "Rule",
+		LogLevel:           "info",
+		ExternalController: "127.0.0.1:9090",
+		DNS:                defaultClashDNS(),
+		ProxyProviders: map[string]ProxyProvider{
+			providerName: {
+				Type:     "http",
+				URL:      providerURL,
+				Interval: 3600,
+				Path:     fmt.Sprintf("./providers/%s.yaml", providerName),
+				HealthCheck: ProviderHealthCheck{
+					Enable:   true,
+					URL:      "http://www.gstatic.com/generate_204",
+					Interval: 300,
+					Lazy:     true,
+				},
+			},
+		},
+		ProxyGroups:   groups,
+		RuleProviders: ruleProviders,
+		Rules:         rules,
+	}
+
+	yamlData, err := yaml.Marshal(&fullConfig)
+	if err != nil {
+		return "", fmt.Errorf("生成Clash配置失败: %v", err)
+	}
+	return string(yamlData), nil
+}
+
+// defaultRules中RULE-SET引用的规则集，客户端使用Loyalsoldier/surge-rules中对应的规则列表
+func defaultRuleProviders() map[string]RuleProvider {
+	ruleSet := func(name, behavior string) RuleProvider {
+		return RuleProvider{
+			Type:     "http",
+			Behavior: behavior,
+			URL:      fmt.Sprintf("https://cdn.jsdelivr.net/gh/Loyalsoldier/clash-rules@release/%s.txt", name),
+			Path:     fmt.Sprintf("./ruleset/%s.yaml", name),
+			Interval: 86400,
+			listURL:  fmt.Sprintf("https://cdn.jsdelivr.net/gh/Loyalsoldier/surge-rules@release/ruleset/%s.txt", name),
+		}
+	}
+	return map[string]RuleProvider{
+		"reject":       ruleSet("reject", "domain"),
+		"china":        ruleSet("direct", "domain"),
+		"cncidr":       ruleSet("cncidr", "ipcidr"),
+		"proxy":        ruleSet("proxy", "domain"),
+		"telegramcidr": ruleSet("telegramcidr", "ipcidr"),
+	}
+}
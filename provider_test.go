@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCountClashConfigProxies(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte("proxies:\n  - {name: r, type: ss, server: 1.1.1.1, port: 443, cipher: aes-128-gcm, password: x}\n"))
+	}))
+	defer server.Close()
+
+	content := `proxies:
+  - {name: a, type: ss, server: 1.1.1.1, port: 443, cipher: aes-128-gcm, password: x}
+proxy-providers:
+  remote:
+    type: http
+    url: ` + server.URL + `
+  inline:
+    type: inline
+    filter: HK
+    payload:
+      - {name: HK 1, type: ss, server: 1.1.1.2, port: 443, cipher: aes-128-gcm, password: x}
+      - {name: JP 1, type: ss, server: 1.1.1.3, port: 443, cipher: aes-128-gcm, password: x}
+`
+	count, remote, err := countClashConfigProxies(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 || remote != 1 {
+		t.Errorf("countClashConfigProxies() = %d nodes, %d remote providers, want 2, 1", count, remote)
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("remote provider fetched %d times, want 0", n)
+	}
+}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Quantumult X支持的节点类型（Clash类型 -> QuanX类型），不在表中的节点会被跳过
+var quanXProxyTypes = map[string]string{
+	"ss":     "shadowsocks",
+	"ssr":    "shadowsocks",
+	"vmess":  "vmess",
+	"vless":  "vless",
+	"trojan": "trojan",
+	"socks5": "socks5",
+	"http":   "http",
+}
+
+// Quantumult X支持的规则类型（Clash类型 -> QuanX类型）
+var quanXRuleTypes = map[string]string{
+	"DOMAIN":         "host",
+	"DOMAIN-SUFFIX":  "host-suffix",
+	"DOMAIN-KEYWORD": "host-keyword",
+	"IP-CIDR":        "ip-cidr",
+	"IP-CIDR6":       "ip6-cidr",
+	"GEOIP":          "geoip",
+	"MATCH":          "final",
+}
+
+// Quantumult X策略组类型（Clash类型 -> QuanX类型）
+var quanXGroupTypes = map[string]string{
+	"select":       "static",
+	"url-test":     "url-latency-benchmark",
+	"fallback":     "available",
+	"load-balance": "round-robin",
+}
+
+// 生成Quantumult X配置，代理组与规则沿用generateFullClashConfig的分组模型
+func generateQuanXConfig(proxies []ProxyConfig, groups []ProxyGroup, rules []string, ruleProviders map[string]RuleProvider) (string, int, error) {
+	log.Printf("开始生成Quantumult X配置，节点数量: %d", len(proxies))
+
+	proxyLines, nodeNames := buildClientProxyLines("Quantumult X", proxies, quanXProxyTypes, quanXProxyLine)
+	if len(proxyLines) == 0 {
+		return "", 0, fmt.Errorf("未找到任何Quantumult X支持的代理节点")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("[general]\n")
+	sb.WriteString("server_check_url = http://www.gstatic.com/generate_204\n")
+	sb.WriteString("excluded_routes = 127.0.0.0/8, 192.168.0.0/16, 10.0.0.0/8, 172.16.0.0/12\n")
+
+	sb.WriteString("\n[dns]\n")
+	sb.WriteString("server = 223.5.5.5\n")
+	sb.WriteString("server = 119.29.29.29\n")
+
+	sb.WriteString("\n[policy]\n")
+	for _, group := range filterClientGroups(groups, nodeNames) {
+		members := make([]string, len(group.Proxies))
+		for i, member := range group.Proxies {
+			members[i] = quanXPolicy(member)
+		}
+		fields := append([]string{group.Name}, members...)
+		if group.Type == "url-test" && group.Interval > 0 {
+			fields = append(fields, "check-interval="+strconv.Itoa(group.Interval))
+		}
+		sb.WriteString(fmt.Sprintf("%s=%s\n", quanXGroupTypes[group.Type], strings.Join(fields, ", ")))
+	}
+
+	sb.WriteString("\n[server_local]\n")
+	for _, line := range proxyLines {
+		sb.WriteString(line + "\n")
+	}
+
+	// QuanX规则不支持no-resolve等附加选项，规则集写入[filter_remote]
+	clientRules, ruleSets := translateClientRules(rules, ruleProviders, quanXRuleTypes, false, quanXPolicy)
+	if len(ruleSets) > 0 {
+		sb.WriteString("\n[filter_remote]\n")
+		for _, ruleSet := range ruleSets {
+			line := fmt.Sprintf("%s, tag=%s, force-policy=%s, opt-parser=true, enabled=true", ruleSet.URL, ruleSet.Name, ruleSet.Policy)
+			if ruleSet.Interval > 0 {
+				line += ", update-interval=" + strconv.Itoa(ruleSet.Interval)
+			}
+			sb.WriteString(line + "\n")
+		}
+	}
+
+	sb.WriteString("\n[filter_local]\n")
+	for _, rule := range clientRules {
+		sb.WriteString(rule + "\n")
+	}
+
+	return sb.String(), len(proxyLines), nil
+}
+
+// QuanX内置策略为小写的direct/reject
+func quanXPolicy(policy string) string {
+	switch policy {
+	case "DIRECT", "REJECT":
+		return strings.ToLower(policy)
+	}
+	return policy
+}
+
+// 生成Quantumult X节点行：类型=服务器:端口, key=value..., tag=名称
+func quanXProxyLine(proxy ProxyConfig, name string) (string, error) {
+	var fields []string
+	add := func(key, value string) {
+		if value != "" {
+			fields = append(fields, key+"="+quoteClientValue(value))
+		}
+	}
+	addTLS := func(serverName string) {
+		add("tls-host", serverName)
+		if proxy.SkipCertVerify {
+			add("tls-verification", "false")
+		}
+	}
+	// 传输层：ws -> obfs=ws/wss，仅TLS时 -> over-tls
+	addTransport := func(tls bool, serverName string) error {
+		switch proxy.Network {
+		case "", "tcp":
+			if tls {
+				add("obfs", "over-tls")
+				add("obfs-host", serverName)
+			}
+		case "ws":
+			if tls {
+				add("obfs", "wss")
+			} else {
+				add("obfs", "ws")
+			}
+			if proxy.WSOpts != nil {
+				add("obfs-host", firstNonEmpty(proxy.WSOpts.Headers["Host"], serverName))
+				add("obfs-uri", proxy.WSOpts.Path)
+			}
+			if tls {
+				add("tls-host", serverName)
+			}
+		default:
+			return fmt.Errorf("Quantumult X不支持的传输方式: %s", proxy.Network)
+		}
+		if tls && proxy.SkipCertVerify {
+			add("tls-verification", "false")
+		}
+		return nil
+	}
+
+	switch proxy.Type {
+	case "ss":
+		add("method", proxy.Cipher)
+		add("password", proxy.Password)
+		switch proxy.Plugin {
+		case "":
+		case "obfs":
+			add("obfs", getString(proxy.PluginOpts, "mode"))
+			add("obfs-host", getString(proxy.PluginOpts, "host"))
+		case "v2ray-plugin":
+			if mode := getString(proxy.PluginOpts, "mode"); mode != "" && mode != "websocket" {
+				return "", fmt.Errorf("Quantumult X不支持的v2ray-plugin模式: %s", mode)
+			}
+			if getBool(proxy.PluginOpts, "tls") {
+				add("obfs", "wss")
+			} else {
+				add("obfs", "ws")
+			}
+			add("obfs-host", getString(proxy.PluginOpts, "host"))
+			add("obfs-uri", getString(proxy.PluginOpts, "path"))
+		default:
+			return "", fmt.Errorf("Quantumult X不支持的SS插件: %s", proxy.Plugin)
+		}
+		if proxy.UDP {
+			add("udp-relay", "true")
+		}
+	case "ssr":
+		add("method", proxy.Cipher)
+		add("password", proxy.Password)
+		add("ssr-protocol", proxy.Protocol)
+		add("ssr-protocol-param", proxy.ProtocolParam)
+		add("obfs", proxy.Obfs)
+		add("obfs-host", proxy.ObfsParam)
+	case "vmess":
+		// QuanX的VMess加密方式不支持auto
+		method := proxy.Cipher
+		if method == "" || method == "auto" {
+			method = "chacha20-poly1305"
+		}
+		add("method", method)
+		add("password", proxy.UUID)
+		if err := addTransport(proxy.TLS, proxy.ServerName); err != nil {
+			return "", err
+		}
+		if proxy.AlterID == 0 {
+			add("aead", "true")
+		} else {
+			add("aead", "false")
+		}
+	case "vless":
+		add("method", "none")
+		add("password", proxy.UUID)
+		if err := addTransport(proxy.TLS, proxy.ServerName); err != nil {
+			return "", err
+		}
+		if proxy.RealityOpts != nil {
+			add("reality-base64-pubkey", proxy.RealityOpts.PublicKey)
+			add("reality-hex-shortid", proxy.RealityOpts.ShortID)
+		}
+		add("vless-flow", proxy.Flow)
+	case "trojan":
+		add("password", proxy.Password)
+		if proxy.Network == "ws" {
+			if err := addTransport(true, proxy.SNI); err != nil {
+				return "", err
+			}
+		} else if proxy.Network != "" && proxy.Network != "tcp" {
+			return "", fmt.Errorf("Quantumult X不支持的传输方式: %s", proxy.Network)
+		} else {
+			add("over-tls", "true")
+			addTLS(proxy.SNI)
+		}
+	case "socks5", "http":
+		add("username", proxy.Username)
+		add("password", proxy.Password)
+		if proxy.TLS {
+			add("over-tls", "true")
+			addTLS(proxy.SNI)
+		}
+	}
+
+	add("tag", name)
+	server := net.JoinHostPort(proxy.Server, strconv.Itoa(proxy.Port))
+	return fmt.Sprintf("%s=%s, %s", quanXProxyTypes[proxy.Type], server, strings.Join(fields, ", ")), nil
+}
+
+// Quantumult X节点行中的类型 -> Clash类型
+var quanXLineTypes = map[string]string{
+	"shadowsocks": "ss",
+	"vmess":       "vmess",
+	"vless":       "vless",
+	"trojan":      "trojan",
+	"http":        "http",
+	"socks5":      "socks5",
+}
+
+// 判断是否为Quantumult X格式的节点行：类型=服务器:端口, key=value...
+func isQuanXProxyLine(line string) bool {
+	key, value, found := strings.Cut(line, "=")
+	if !found {
+		return false
+	}
+	if _, ok := quanXLineTypes[strings.ToLower(strings.TrimSpace(key))]; !ok {
+		return false
+	}
+	fields := splitProxyLineFields(value)
+	return len(fields) > 0 && strings.Contains(fields[0], ":") && !strings.Contains(fields[0], "=")
+}
+
+// 解析Quantumult X格式的节点行
+func parseQuanXProxyLine(line string) (ProxyConfig, error) {
+	var proxy ProxyConfig
+	lineType, value, _ := strings.Cut(line, "=")
+	proxy.Type = quanXLineTypes[strings.ToLower(strings.TrimSpace(lineType))]
+
+	fields := splitProxyLineFields(value)
+	host, portStr, err := net.SplitHostPort(fields[0])
+	if err != nil {
+		return proxy, fmt.Errorf("无效的服务器地址: %v", err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return proxy, fmt.Errorf("无效的端口号: %v", err)
+	}
+	proxy.Server = host
+	proxy.Port = port
+
+	params := make(map[string]string)
+	for _, field := range fields[1:] {
+		key, val, _ := strings.Cut(field, "=")
+		params[strings.ToLower(strings.TrimSpace(key))] = unquoteProxyLineValue(val)
+	}
+	proxy.Name = params["tag"]
+	proxy.SkipCertVerify = params["tls-verification"] == "false"
+	obfs := params["obfs"]
+
+	// obfs=ws/wss为WebSocket传输，over-tls/wss表示启用TLS
+	setTransport := func() error {
+		switch obfs {
+		case "", "over-tls":
+		case "ws", "wss":
+			proxy.Network = "ws"
+			proxy.WSOpts = &WSOptions{Path: params["obfs-uri"]}
+			if host := params["obfs-host"]; host != "" {
+				proxy.WSOpts.Headers = map[string]string{"Host": host}
+			}
+		default:
+			return fmt.Errorf("不支持的传输方式: %s", obfs)
+		}
+		return nil
+	}
+	tls := obfs == "over-tls" || obfs == "wss" || isTruthy(params["over-tls"])
+	serverName := params["tls-host"]
+	if obfs == "over-tls" {
+		serverName = firstNonEmpty(serverName, params["obfs-host"])
+	}
+
+	switch proxy.Type {
+	case "ss":
+		proxy.Cipher = params["method"]
+		proxy.Password = params["password"]
+		proxy.UDP = isTruthy(params["udp-relay"])
+		if protocol := params["ssr-protocol"]; protocol != "" {
+			proxy.Type = "ssr"
+			proxy.Protocol = protocol
+			proxy.ProtocolParam = params["ssr-protocol-param"]
+			proxy.Obfs = firstNonEmpty(obfs, "plain")
+			proxy.ObfsParam = params["obfs-host"]
+			break
+		}
+		switch obfs {
+		case "":
+		case "http", "tls":
+			proxy.Plugin = "obfs"
+			proxy.PluginOpts = map[string]interface{}{"mode": obfs}
+			if host := params["obfs-host"]; host != "" {
+				proxy.PluginOpts["host"] = host
+			}
+		case "ws", "wss":
+			proxy.Plugin = "v2ray-plugin"
+			proxy.PluginOpts = map[string]interface{}{"mode": "websocket"}
+			if obfs == "wss" {
+				proxy.PluginOpts["tls"] = true
+			}
+			if host := params["obfs-host"]; host != "" {
+				proxy.PluginOpts["host"] = host
+			}
+			if path := params["obfs-uri"]; path != "" {
+				proxy.PluginOpts["path"] = path
+			}
+		default:
+			return proxy, fmt.Errorf("不支持的SS混淆: %s", obfs)
+		}
+	case "vmess", "vless":
+		proxy.UUID = params["password"]
+		if proxy.Type == "vmess" {
+			proxy.Cipher = firstNonEmpty(params["method"], "auto")
+		}
+		if err := setTransport(); err != nil {
+			return proxy, err
+		}
+		proxy.TLS = tls
+		proxy.ServerName = serverName
+		proxy.Flow = params["vless-flow"]
+		if publicKey := params["reality-base64-pubkey"]; publicKey != "" {
+			proxy.TLS = true
+			proxy.RealityOpts = &RealityOptions{PublicKey: publicKey, ShortID: params["reality-hex-shortid"]}
+		}
+	case "trojan":
+		proxy.Password = params["password"]
+		if err := setTransport(); err != nil {
+			return proxy, err
+		}
+		proxy.SNI = serverName
+	case "http", "socks5":
+		proxy.Username = params["username"]
+		proxy.Password = params["password"]
+		proxy.TLS = tls
+		proxy.SNI = serverName
+	}
+
+	if proxy.Name == "" {
+		proxy.Name = fmt.Sprintf("%s:%d", proxy.Server, proxy.Port)
+	}
+	return proxy, nil
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// 地区定义：Code为ISO国家/地区代码，Name为中文名称
+type Region struct {
+	Code    string
+	Name    string
+	Flag    string
+	pattern *regexp.Regexp
+}
+
+// 节点名称中的地区关键词表，中文关键词按子串匹配，英文关键词需以非字母字符分隔，
+// 避免 US 误匹配 Russia、GB 误匹配 100GB 之类的名称
+var regions = []Region{
+	newRegion("HK", "香港", "香港|港", "hk|hkg|hong ?kong"),
+	newRegion("TW", "台湾", "台湾|台灣|台北|台中|新北|彰化", "tw|twn|taiwan|taipei"),
+	newRegion("JP", "日本", "日本|东京|東京|大阪|埼玉|沪日|深日|川日", "jp|jpn|japan|tokyo|osaka"),
+	newRegion("SG", "新加坡", "新加坡|狮城|獅城", "sg|sgp|singapore"),
+	newRegion("US", "美国", "美国|美國|洛杉矶|圣何塞|硅谷|西雅图|纽约|芝加哥|达拉斯|凤凰城|波特兰", "us|usa|united ?states|america|los ?angeles|san ?jose|silicon ?valley|seattle|new ?york|chicago|dallas"),
+	newRegion("KR", "韩国", "韩国|韓國|首尔|首爾|春川", "kr|kor|korea|seoul"),
+	newRegion("GB", "英国", "英国|英國|伦敦|倫敦", "uk|gb|united ?kingdom|britain|england|london"),
+	newRegion("DE", "德国", "德国|德國|法兰克福", "de|deu|germany|frankfurt"),
+	newRegion("FR", "法国", "法国|法國|巴黎", "fr|fra|france|paris"),
+	newRegion("NL", "荷兰", "荷兰|荷蘭|阿姆斯特丹", "nl|nld|netherlands|amsterdam"),
+	newRegion("CA", "加拿大", "加拿大|多伦多|温哥华|蒙特利尔", "ca|canada|toronto|vancouver|montreal"),
+	newRegion("AU", "澳大利亚", "澳大利亚|澳洲|悉尼|墨尔本", "au|aus|australia|sydney|melbourne"),
+	newRegion("RU", "俄罗斯", "俄罗斯|俄羅斯|莫斯科", "ru|rus|russia|moscow"),
+	newRegion("IN", "印度", "印度|孟买", "ind|india|mumbai"),
+	newRegion("TR", "土耳其", "土耳其|伊斯坦布尔", "tr|tur|turkey|istanbul"),
+	newRegion("MY", "马来西亚", "马来西亚|馬來西亞|吉隆坡", "mys|malaysia|kuala ?lumpur"),
+	newRegion("TH", "泰国", "泰国|泰國|曼谷", "th|tha|thailand|bangkok"),
+	newRegion("VN", "越南", "越南|胡志明", "vn|vnm|vietnam"),
+	newRegion("PH", "菲律宾", "菲律宾|菲律賓|马尼拉", "ph|phl|philippines|manila"),
+	newRegion("ID", "印度尼西亚", "印度尼西亚|印尼|雅加达", "idn|indonesia|jakarta"),
+	newRegion("AR", "阿根廷", "阿根廷", "ar|arg|argentina"),
+	newRegion("BR", "巴西", "巴西|圣保罗", "br|bra|brazil|sao ?paulo"),
+}
+
+// 根据中英文关键词构造地区，节点名称中的旗帜符号同样视为关键词
+func newRegion(code, name, chineseKeywords, englishKeywords string) Region {
+	flag := regionFlag(code)
+	expr := `(?i)(?:` + flag + `|` + chineseKeywords + `)|(?:^|[^a-z0-9])(?:` + englishKeywords + `)(?:[^a-z]|$)`
+	return Region{Code: code, Name: name, Flag: flag, pattern: regexp.MustCompile(expr)}
+}
+
+// 由两位地区代码生成旗帜符号
+func regionFlag(code string) string {
+	var flag strings.Builder
+	for _, c := range strings.ToUpper(code) {
+		flag.WriteRune(0x1F1E6 + c - 'A')
+	}
+	return flag.String()
+}
+
+// 按地区代码查找地区定义，不在关键词表中的代码（如GeoIP返回的其他国家）以代码作为名称
+func regionByCode(code string) (Region, bool) {
+	for _, region := range regions {
+		if region.Code == code {
+			return region, true
+		}
+	}
+	if len(code) != 2 || !isASCIILetters(code) {
+		return Region{}, false
+	}
+	code = strings.ToUpper(code)
+	return Region{Code: code, Name: code, Flag: regionFlag(code)}, true
+}
+
+// 是否只包含ASCII字母
+func isASCIILetters(s string) bool {
+	for _, c := range s {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// 根据节点名称识别地区，多个地区同时出现时取名称中最靠前的一个
+func detectRegionByName(name string) string {
+	code, first := "", -1
+	for _, region := range regions {
+		if loc := region.pattern.FindStringIndex(name); loc != nil && (first < 0 || loc[0] < first) {
+			code, first = region.Code, loc[0]
+		}
+	}
+	return code
+}
+
+// 为节点识别地区：优先按名称关键词，识别不到时批量查询离线GeoIP数据库
+func detectProxyRegions(proxies []ProxyConfig) {
+	var unknown []string
+	for i := range proxies {
+		if proxies[i].region = detectRegionByName(proxies[i].Name); proxies[i].region == "" {
+			unknown = append(unknown, proxies[i].Server)
+		}
+	}
+	if len(unknown) == 0 {
+		return
+	}
+	countries := lookupServerCountries(unknown)
+	for i := range proxies {
+		if proxies[i].region == "" {
+			proxies[i].region = countries[proxies[i].Server]
+		}
+	}
+}
+
+// 将节点地区转换为保存到数据库的字符串
+func regionsString(regions map[string]string) string {
+	if regions == nil {
+		return ""
+	}
+	data, _ := json.Marshal(regions)
+	return string(data)
+}
+
+// 读取数据库中保存的节点地区，未保存时返回nil
+func parseRegions(data string) (map[string]string, error) {
+	if data == "" {
+		return nil, nil
+	}
+	var regions map[string]string
+	err := json.Unmarshal([]byte(data), &regions)
+	return regions, err
+}
+
+// 恢复生成订阅时保存的节点地区，旧订阅没有保存地区时重新识别
+func restoreProxyRegions(proxies []ProxyConfig, regions map[string]string) {
+	if regions == nil {
+		detectProxyRegions(proxies)
+		return
+	}
+	for i := range proxies {
+		proxies[i].region = regions[proxies[i].Name]
+	}
+}
+
+// 生成地区分组的地区，其余地区的节点归入“其他地区”分组
+var regionGroupCodes = []string{"HK", "JP", "US", "SG", "TW"}
+
+const otherRegionGroupName = "🌐 其他地区"
+
+// 地区分组支持的代理组类型
+var regionGroupTypes = map[string]bool{
+	"url-test": true,
+	"fallback": true,
+}
+
+// 按节点地区生成url-test或fallback代理组，插入到自动选择之后，并嵌套到主选择组中
+func addRegionProxyGroups(groups []ProxyGroup, proxies []ProxyConfig, groupType string) []ProxyGroup {
+	members := make(map[string][]string)
+	for _, proxy := range proxies {
+		code := ""
+		for _, groupCode := range regionGroupCodes {
+			if proxy.region == groupCode {
+				code = groupCode
+			}
+		}
+		members[code] = append(members[code], proxy.Name)
+	}
+
+	var regionGroups []ProxyGroup
+	for _, code := range regionGroupCodes {
+		if len(members[code]) > 0 {
+			region, _ := regionByCode(code)
+			regionGroups = append(regionGroups, ProxyGroup{Name: regionGroupName(region), Proxies: members[code]})
+		}
+	}
+	if len(members[""]) > 0 {
+		regionGroups = append(regionGroups, ProxyGroup{Name: otherRegionGroupName, Proxies: members[""]})
+	}
+	return insertRegionProxyGroups(groups, regionGroups, proxies, groupType)
+}
+
+// 节点来自proxy-provider时的地区分组：provider中的节点会随订阅更新，
+// 每个地区都生成分组，按名称关键词从provider中筛选节点，其他地区分组排除上述地区
+func addProviderRegionProxyGroups(groups []ProxyGroup, proxies []ProxyConfig, groupType, providerName string) []ProxyGroup {
+	var regionGroups []ProxyGroup
+	var patterns []string
+	for _, code := range regionGroupCodes {
+		region, _ := regionByCode(code)
+		patterns = append(patterns, region.pattern.String())
+		regionGroups = append(regionGroups, ProxyGroup{
+			Name:   regionGroupName(region),
+			Use:    []string{providerName},
+			Filter: region.pattern.String(),
+		})
+	}
+	regionGroups = append(regionGroups, ProxyGroup{
+		Name:          otherRegionGroupName,
+		Use:           []string{providerName},
+		ExcludeFilter: strings.Join(patterns, "`"),
+	})
+	return insertRegionProxyGroups(groups, regionGroups, proxies, groupType)
+}
+
+func regionGroupName(region Region) string {
+	return region.Flag + " " + region.Name + "节点"
+}
+
+// 设置地区分组的类型及测速参数，插入到自动选择之后，并嵌套到主选择组中
+func insertRegionProxyGroups(groups, regionGroups []ProxyGroup, proxies []ProxyConfig, groupType string) []ProxyGroup {
+	// 分组名称不能与节点、已有代理组及内置策略重名
+	used := map[string]bool{"DIRECT": true, "REJECT": true}
+	for _, group := range groups {
+		used[group.Name] = true
+	}
+	for _, proxy := range proxies {
+		used[proxy.Name] = true
+	}
+
+	var regionGroupNames []string
+	for i := range regionGroups {
+		regionGroups[i].Name = uniqueName(regionGroups[i].Name, used)
+		regionGroups[i].Type = groupType
+		regionGroups[i].URL = "http://www.gstatic.com/generate_204"
+		regionGroups[i].Interval = 300
+		regionGroupNames = append(regionGroupNames, regionGroups[i].Name)
+	}
+
+	var result []ProxyGroup
+	for _, group := range groups {
+		if group.Name == "🔰 节点选择" {
+			var selected []string
+			for _, member := range group.Proxies {
+				selected = append(selected, member)
+				if member == "♻️ 自动选择" {
+					selected = append(selected, regionGroupNames...)
+				}
+			}
+			group.Proxies = selected
+		}
+		result = append(result, group)
+		if group.Name == "♻️ 自动选择" {
+			result = append(result, regionGroups...)
+		}
+	}
+	return result
+}
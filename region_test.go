@@ -0,0 +1,139 @@
+package main
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestDetectRegionByName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"🇭🇰 香港 01", "HK"},
+		{"HK-01", "HK"},
+		{"美国 洛杉矶", "US"},
+		{"Los Angeles 02", "US"},
+		{"Russia Moscow", "RU"},
+		{"香港-日本 中转", "HK"},
+		{"日本 经 香港", "JP"},
+		{"Tokyo|IPLC", "JP"},
+		{"USB 专线", ""},
+		{"剩余流量：100GB", ""},
+	}
+	for _, tt := range tests {
+		if got := detectRegionByName(tt.name); got != tt.want {
+			t.Errorf("detectRegionByName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestProcessProxiesDetectsRegionsOnlyWhenNeeded(t *testing.T) {
+	proxies := []ProxyConfig{{Name: "香港 01", Type: "ss", Server: "1.1.1.1", Port: 443}}
+
+	processed, err := processProxies(proxies, ProxyOptions{RenameRules: []RenameRule{{Template: "节点 {index}"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if processed[0].region != "" {
+		t.Errorf("region detected without any option needing it: %q", processed[0].region)
+	}
+
+	processed, err = processProxies(proxies, ProxyOptions{RenameRules: []RenameRule{{Template: "{flag} {index}"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if processed[0].region != "HK" || processed[0].Name != "🇭🇰 01" {
+		t.Errorf("processed = %q region %q, want 🇭🇰 01 region HK", processed[0].Name, processed[0].region)
+	}
+}
+
+func TestRegionGroupNamesAvoidProxyNames(t *testing.T) {
+	proxies := []ProxyConfig{
+		{Name: "🇭🇰 香港节点", region: "HK"},
+		{Name: "香港 02", region: "HK"},
+	}
+	groups := addRegionProxyGroups(defaultProxyGroups([]string{proxies[0].Name, proxies[1].Name}), proxies, "url-test")
+
+	var regionGroup *ProxyGroup
+	for i := range groups {
+		if groups[i].Name == proxies[0].Name {
+			t.Fatalf("region group reuses proxy name %q", proxies[0].Name)
+		}
+		if groups[i].Name == "🇭🇰 香港节点 2" {
+			regionGroup = &groups[i]
+		}
+	}
+	if regionGroup == nil {
+		t.Fatalf("region group 🇭🇰 香港节点 2 not found in %v", groups)
+	}
+	if len(regionGroup.Proxies) != 2 {
+		t.Errorf("region group proxies = %v, want both HK proxies", regionGroup.Proxies)
+	}
+}
+
+func TestSubscriptionKeepsRegionsAfterRename(t *testing.T) {
+	proxies := []ProxyConfig{
+		{Name: "香港 01", Type: "ss", Server: "1.1.1.1", Port: 443, Cipher: "aes-128-gcm", Password: "pass"},
+		{Name: "日本 01", Type: "ss", Server: "1.1.1.2", Port: 443, Cipher: "aes-128-gcm", Password: "pass"},
+	}
+	options := ProxyOptions{RenameRules: []RenameRule{{Template: "节点 {index}"}}, RegionGroups: "url-test"}
+	content, _, regions, err := convertClashToSubscription(ClashConfig{Proxies: proxies}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 重命名后的名称中已没有地区关键词，需使用生成订阅时保存的地区
+	parsed, err := parseSubscriptionContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restoreProxyRegions(parsed, regions)
+	got := make(map[string]string)
+	for _, proxy := range parsed {
+		got[proxy.Name] = proxy.region
+	}
+	want := map[string]string{"节点 01": "HK", "节点 02": "JP"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("restored regions = %v, want %v", got, want)
+	}
+
+	stored, err := parseRegions(regionsString(regions))
+	if err != nil || !reflect.DeepEqual(stored, regions) {
+		t.Errorf("parseRegions(regionsString(%v)) = %v, %v", regions, stored, err)
+	}
+}
+
+func TestProviderRegionProxyGroups(t *testing.T) {
+	t.Setenv("CONFIG_TEMPLATE", "")
+	groups, _, _, _ := buildProviderGroupsAndRules("subscription-1", nil, ProxyOptions{RegionGroups: "fallback"})
+
+	hk := findProxyGroup(groups, "🇭🇰 香港节点")
+	if hk == nil || hk.Type != "fallback" || !reflect.DeepEqual(hk.Use, []string{"subscription-1"}) || len(hk.Proxies) != 0 {
+		t.Fatalf("🇭🇰 香港节点 = %+v", hk)
+	}
+	filter := regexp.MustCompile(hk.Filter)
+	if !filter.MatchString("香港 01") || filter.MatchString("日本 01") {
+		t.Errorf("filter %q does not select HK nodes only", hk.Filter)
+	}
+	other := findProxyGroup(groups, otherRegionGroupName)
+	if other == nil || other.Filter != "" || !strings.Contains(other.ExcludeFilter, "`") {
+		t.Errorf("%s = %+v", otherRegionGroupName, other)
+	}
+	if selector := findProxyGroup(groups, "🔰 节点选择"); selector == nil || selector.Proxies[1] != "🇭🇰 香港节点" {
+		t.Errorf("🔰 节点选择 = %+v, want region groups after ♻️ 自动选择", selector)
+	}
+}
+
+func TestRegionGroupsRejectedWithTemplate(t *testing.T) {
+	t.Setenv("CONFIG_TEMPLATE", "")
+	if err := validateProxyOptions(ProxyOptions{RegionGroups: "url-test", Template: "acl4ssr.ini"}); err == nil {
+		t.Error("validateProxyOptions accepted region groups with a template")
+	}
+	t.Setenv("CONFIG_TEMPLATE", "https://example.com/acl4ssr.ini")
+	if err := validateProxyOptions(ProxyOptions{RegionGroups: "url-test"}); err == nil {
+		t.Error("validateProxyOptions accepted region groups with CONFIG_TEMPLATE")
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 节点重命名规则，按顺序依次应用：
+// Pattern非空时只处理名称匹配的节点；Template为空时用Replace做正则替换（支持$1等分组引用），
+// Template非空时按模板生成新名称，可用变量 {name} {type} {server} {port} {region} {flag} {index}
+type RenameRule struct {
+	Pattern  string `json:"pattern,omitempty"`
+	Replace  string `json:"replace,omitempty"`
+	Template string `json:"template,omitempty"`
+}
+
+// 编译后的重命名规则
+type compiledRenameRule struct {
+	RenameRule
+	pattern *regexp.Regexp
+}
+
+// 重命名规则的JSON表示，用于保存到数据库和计算配置哈希，未设置时为空字符串
+func renameRulesString(rules []RenameRule) string {
+	if len(rules) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(rules)
+	return string(data)
+}
+
+// 读取数据库中保存的重命名规则
+func parseRenameRules(data string) ([]RenameRule, error) {
+	var rules []RenameRule
+	if data == "" {
+		return nil, nil
+	}
+	err := json.Unmarshal([]byte(data), &rules)
+	return rules, err
+}
+
+// 检查重命名规则是否有效
+func validateRenameRules(rules []RenameRule) error {
+	_, err := compileRenameRules(rules)
+	return err
+}
+
+func compileRenameRules(rules []RenameRule) ([]compiledRenameRule, error) {
+	compiled := make([]compiledRenameRule, 0, len(rules))
+	for i, rule := range rules {
+		if rule.Pattern == "" && rule.Template == "" {
+			return nil, fmt.Errorf("第 %d 条重命名规则缺少pattern或template", i+1)
+		}
+		var pattern *regexp.Regexp
+		if rule.Pattern != "" {
+			var err error
+			if pattern, err = regexp.Compile(rule.Pattern); err != nil {
+				return nil, fmt.Errorf("第 %d 条重命名规则的正则无效: %v", i+1, err)
+			}
+		}
+		compiled = append(compiled, compiledRenameRule{RenameRule: rule, pattern: pattern})
+	}
+	return compiled, nil
+}
+
+// 按规则重命名节点
+func renameProxies(proxies []ProxyConfig, rules []RenameRule) ([]ProxyConfig, error) {
+	compiled, err := compileRenameRules(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	renamed := make([]ProxyConfig, len(proxies))
+	copy(renamed, proxies)
+	for _, rule := range compiled {
+		// {index}为本条规则命中节点的序号，位数不足时补零
+		var matched []int
+		for i := range renamed {
+			if rule.pattern == nil || rule.pattern.MatchString(renamed[i].Name) {
+				matched = append(matched, i)
+			}
+		}
+		width := len(strconv.Itoa(len(matched)))
+		if width < 2 {
+			width = 2
+		}
+		for n, i := range matched {
+			proxy := &renamed[i]
+			if rule.Template == "" {
+				proxy.Name = rule.pattern.ReplaceAllString(proxy.Name, rule.Replace)
+				continue
+			}
+			region, _ := regionByCode(proxy.region)
+			proxy.Name = strings.NewReplacer(
+				"{name}", proxy.Name,
+				"{type}", proxy.Type,
+				"{server}", proxy.Server,
+				"{port}", strconv.Itoa(proxy.Port),
+				"{region}", proxy.region,
+				"{flag}", region.Flag,
+				"{index}", fmt.Sprintf("%0*d", width, n+1),
+			).Replace(rule.Template)
+		}
+	}
+
+	for i := range renamed {
+		renamed[i].Name = strings.TrimSpace(renamed[i].Name)
+		if renamed[i].Name == "" {
+			renamed[i].Name = fmt.Sprintf("%s:%d", renamed[i].Server, renamed[i].Port)
+		}
+	}
+	return renamed, nil
+}
+
+// 为重名节点追加序号后缀（Clash不允许代理组中出现重名节点），如 "HK 01"、"HK 01 2"
+func uniqueProxyNames(proxies []ProxyConfig) []ProxyConfig {
+	used := make(map[string]bool, len(proxies))
+	for i := range proxies {
+		proxies[i].Name = uniqueName(proxies[i].Name, used)
+	}
+	return proxies
+}
+
+// 返回不在used中的名称（重名时追加序号），并将其加入used
+func uniqueName(base string, used map[string]bool) string {
+	name := base
+	for n := 2; used[name]; n++ {
+		name = fmt.Sprintf("%s %d", base, n)
+	}
+	used[name] = true
+	return name
+}
@@ -0,0 +1,706 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// sing-box完整配置结构，按sing-box 1.12及以上版本的格式生成
+// （新格式的DNS服务器、路由动作、WireGuard端点）
+type SingBoxConfig struct {
+	Log       map[string]interface{}   `json:"log"`
+	DNS       map[string]interface{}   `json:"dns"`
+	Inbounds  []map[string]interface{} `json:"inbounds"`
+	Outbounds []map[string]interface{} `json:"outbounds"`
+	Endpoints []map[string]interface{} `json:"endpoints,omitempty"`
+	Route     map[string]interface{}   `json:"route"`
+}
+
+// sing-box中与Clash代理组对应的出站标签
+const (
+	singBoxSelectorTag = "🔰 节点选择"
+	singBoxURLTestTag  = "♻️ 自动选择"
+	singBoxDirectTag   = "direct"
+)
+
+// 生成完整的sing-box配置（与generateFullClashConfig对应）
+func generateSingBoxConfig(proxies []ProxyConfig) (string, int, error) {
+	log.Printf("开始生成sing-box配置，节点数量: %d", len(proxies))
+
+	var nodeOutbounds, endpoints []map[string]interface{}
+	var nodeTags []string
+	for _, proxy := range proxies {
+		outbounds, err := singBoxOutbounds(proxy)
+		if err != nil {
+			log.Printf("跳过sing-box不支持的节点 %s: %v", proxy.Name, err)
+			continue
+		}
+		// WireGuard在sing-box 1.11起写在endpoints中，出站组仍可按标签引用
+		if proxy.Type == "wireguard" {
+			endpoints = append(endpoints, outbounds...)
+		} else {
+			nodeOutbounds = append(nodeOutbounds, outbounds...)
+		}
+		nodeTags = append(nodeTags, proxy.Name)
+	}
+
+	if len(nodeTags) == 0 {
+		return "", 0, fmt.Errorf("未找到任何sing-box支持的代理节点")
+	}
+
+	outbounds := []map[string]interface{}{
+		{
+			"type":      "selector",
+			"tag":       singBoxSelectorTag,
+			"outbounds": append([]string{singBoxURLTestTag, singBoxDirectTag}, nodeTags...),
+			"default":   singBoxURLTestTag,
+		},
+		{
+			"type":      "urltest",
+			"tag":       singBoxURLTestTag,
+			"outbounds": nodeTags,
+			"url":       "http://www.gstatic.com/generate_204",
+			"interval":  "5m",
+		},
+	}
+	outbounds = append(outbounds, nodeOutbounds...)
+	outbounds = append(outbounds, map[string]interface{}{"type": "direct", "tag": singBoxDirectTag})
+
+	config := SingBoxConfig{
+		Log: map[string]interface{}{
+			"level":     "info",
+			"timestamp": true,
+		},
+		DNS: map[string]interface{}{
+			"servers": []map[string]interface{}{
+				{"type": "https", "tag": "dns-remote", "server": "8.8.8.8", "detour": singBoxSelectorTag},
+				{"type": "https", "tag": "dns-direct", "server": "223.5.5.5"},
+			},
+			"rules": []map[string]interface{}{
+				{"rule_set": "geosite-cn", "server": "dns-direct"},
+			},
+			"final":    "dns-remote",
+			"strategy": "prefer_ipv4",
+		},
+		Inbounds: []map[string]interface{}{
+			{
+				"type":        "mixed",
+				"tag":         "mixed-in",
+				"listen":      "127.0.0.1",
+				"listen_port": 7890,
+			},
+			{
+				"type":         "tun",
+				"tag":          "tun-in",
+				"address":      []string{"172.19.0.1/30"},
+				"auto_route":   true,
+				"strict_route": true,
+				"stack":        "mixed",
+			},
+		},
+		Outbounds: outbounds,
+		Endpoints: endpoints,
+		Route: map[string]interface{}{
+			"rules": []map[string]interface{}{
+				{"action": "sniff"},
+				{"protocol": "dns", "action": "hijack-dns"},
+				{"ip_is_private": true, "outbound": singBoxDirectTag},
+				{"rule_set": []string{"geosite-cn", "geoip-cn"}, "outbound": singBoxDirectTag},
+			},
+			"rule_set": []map[string]interface{}{
+				{
+					"type":            "remote",
+					"tag":             "geosite-cn",
+					"format":          "binary",
+					"url":             "https://raw.githubusercontent.com/SagerNet/sing-geosite/rule-set/geosite-cn.srs",
+					"download_detour": singBoxSelectorTag,
+				},
+				{
+					"type":            "remote",
+					"tag":             "geoip-cn",
+					"format":          "binary",
+					"url":             "https://raw.githubusercontent.com/SagerNet/sing-geoip/rule-set/geoip-cn.srs",
+					"download_detour": singBoxSelectorTag,
+				},
+			},
+			"final":                 singBoxSelectorTag,
+			"auto_detect_interface": true,
+			// 节点服务器域名使用直连DNS解析（代替旧版 outbound: any 的DNS规则）
+			"default_domain_resolver": "dns-direct",
+		},
+	}
+
+	// 不转义HTML字符，保持节点名称和URL的可读性
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(config); err != nil {
+		return "", 0, fmt.Errorf("生成sing-box配置失败: %v", err)
+	}
+
+	return buf.String(), len(nodeTags), nil
+}
+
+// 将代理节点转换为sing-box出站（WireGuard为端点），shadow-tls插件需要额外的detour出站
+func singBoxOutbounds(proxy ProxyConfig) ([]map[string]interface{}, error) {
+	outbound := map[string]interface{}{
+		"tag":         proxy.Name,
+		"server":      proxy.Server,
+		"server_port": proxy.Port,
+	}
+
+	switch proxy.Type {
+	case "ss":
+		outbound["type"] = "shadowsocks"
+		outbound["method"] = proxy.Cipher
+		outbound["password"] = proxy.Password
+		switch proxy.Plugin {
+		case "":
+		case "obfs", "v2ray-plugin":
+			// sing-box使用SIP003插件字符串，去掉插件名部分即为plugin_opts
+			pluginStr := formatSSPlugin(proxy.Plugin, proxy.PluginOpts)
+			name, opts, _ := strings.Cut(pluginStr, ";")
+			outbound["plugin"] = name
+			outbound["plugin_opts"] = opts
+		case "shadow-tls":
+			detourTag := proxy.Name + " (shadow-tls)"
+			shadowTLS := map[string]interface{}{
+				"type":        "shadowtls",
+				"tag":         detourTag,
+				"server":      proxy.Server,
+				"server_port": proxy.Port,
+				"version":     getInt(proxy.PluginOpts, "version"),
+				"password":    getString(proxy.PluginOpts, "password"),
+				"tls": map[string]interface{}{
+					"enabled":     true,
+					"server_name": getString(proxy.PluginOpts, "host"),
+				},
+			}
+			delete(outbound, "server")
+			delete(outbound, "server_port")
+			outbound["detour"] = detourTag
+			return []map[string]interface{}{outbound, shadowTLS}, nil
+		default:
+			return nil, fmt.Errorf("不支持的SS插件: %s", proxy.Plugin)
+		}
+	case "vmess":
+		outbound["type"] = "vmess"
+		outbound["uuid"] = proxy.UUID
+		outbound["security"] = proxy.Cipher
+		outbound["alter_id"] = proxy.AlterID
+		if err := setSingBoxTransport(outbound, proxy); err != nil {
+			return nil, err
+		}
+		if proxy.TLS {
+			outbound["tls"] = singBoxTLS(proxy, proxy.ServerName)
+		}
+	case "vless":
+		outbound["type"] = "vless"
+		outbound["uuid"] = proxy.UUID
+		if proxy.Flow != "" {
+			outbound["flow"] = proxy.Flow
+		}
+		outbound["packet_encoding"] = "xudp"
+		if err := setSingBoxTransport(outbound, proxy); err != nil {
+			return nil, err
+		}
+		if proxy.TLS {
+			outbound["tls"] = singBoxTLS(proxy, proxy.ServerName)
+		}
+	case "trojan":
+		outbound["type"] = "trojan"
+		outbound["password"] = proxy.Password
+		if err := setSingBoxTransport(outbound, proxy); err != nil {
+			return nil, err
+		}
+		outbound["tls"] = singBoxTLS(proxy, proxy.SNI)
+	case "hysteria2":
+		outbound["type"] = "hysteria2"
+		outbound["password"] = proxy.Password
+		if proxy.Ports != "" {
+			delete(outbound, "server_port")
+			outbound["server_ports"] = singBoxPortRanges(proxy.Ports)
+		}
+		setSingBoxBandwidth(outbound, proxy)
+		if proxy.Obfs != "" {
+			outbound["obfs"] = map[string]interface{}{
+				"type":     proxy.Obfs,
+				"password": proxy.ObfsPassword,
+			}
+		}
+		outbound["tls"] = singBoxTLS(proxy, proxy.SNI)
+	case "hysteria":
+		outbound["type"] = "hysteria"
+		if proxy.AuthStr != "" {
+			outbound["auth_str"] = proxy.AuthStr
+		}
+		if proxy.Ports != "" {
+			delete(outbound, "server_port")
+			outbound["server_ports"] = singBoxPortRanges(proxy.Ports)
+		}
+		setSingBoxBandwidth(outbound, proxy)
+		if proxy.Obfs != "" {
+			outbound["obfs"] = proxy.Obfs
+		}
+		outbound["tls"] = singBoxTLS(proxy, proxy.SNI)
+	case "tuic":
+		outbound["type"] = "tuic"
+		outbound["uuid"] = proxy.UUID
+		outbound["password"] = proxy.Password
+		if proxy.CongestionController != "" {
+			outbound["congestion_control"] = proxy.CongestionController
+		}
+		if proxy.UDPRelayMode != "" {
+			outbound["udp_relay_mode"] = proxy.UDPRelayMode
+		}
+		tls := singBoxTLS(proxy, proxy.SNI)
+		if proxy.DisableSNI {
+			tls["disable_sni"] = true
+		}
+		outbound["tls"] = tls
+	case "anytls":
+		outbound["type"] = "anytls"
+		outbound["password"] = proxy.Password
+		outbound["tls"] = singBoxTLS(proxy, proxy.SNI)
+	case "wireguard":
+		return []map[string]interface{}{singBoxWireGuardEndpoint(proxy)}, nil
+	case "socks5":
+		if proxy.TLS {
+			return nil, fmt.Errorf("sing-box的SOCKS出站不支持TLS")
+		}
+		outbound["type"] = "socks"
+		outbound["version"] = "5"
+		if proxy.Username != "" {
+			outbound["username"] = proxy.Username
+			outbound["password"] = proxy.Password
+		}
+	case "http":
+		outbound["type"] = "http"
+		if proxy.Username != "" {
+			outbound["username"] = proxy.Username
+			outbound["password"] = proxy.Password
+		}
+		if proxy.TLS {
+			outbound["tls"] = singBoxTLS(proxy, proxy.SNI)
+		}
+	default:
+		return nil, fmt.Errorf("sing-box不支持的节点类型: %s", proxy.Type)
+	}
+
+	return []map[string]interface{}{outbound}, nil
+}
+
+// 生成sing-box的WireGuard端点，单对端的节点也写为peers
+func singBoxWireGuardEndpoint(proxy ProxyConfig) map[string]interface{} {
+	var address []string
+	if proxy.IP != "" {
+		address = append(address, withPrefixLength(proxy.IP, 32))
+	}
+	if proxy.IPv6 != "" {
+		address = append(address, withPrefixLength(proxy.IPv6, 128))
+	}
+
+	peers := proxy.Peers
+	if len(peers) == 0 {
+		peers = []WireGuardPeer{{
+			Server:       proxy.Server,
+			Port:         proxy.Port,
+			PublicKey:    proxy.PublicKey,
+			PreSharedKey: proxy.PreSharedKey,
+			Reserved:     proxy.Reserved,
+		}}
+	}
+	var endpointPeers []map[string]interface{}
+	for _, peer := range peers {
+		endpointPeer := map[string]interface{}{
+			"address":     peer.Server,
+			"port":        peer.Port,
+			"public_key":  peer.PublicKey,
+			"allowed_ips": peer.AllowedIPs,
+		}
+		if len(peer.AllowedIPs) == 0 {
+			endpointPeer["allowed_ips"] = []string{"0.0.0.0/0", "::/0"}
+		}
+		if peer.PreSharedKey != "" {
+			endpointPeer["pre_shared_key"] = peer.PreSharedKey
+		}
+		if len(peer.Reserved) > 0 {
+			endpointPeer["reserved"] = peer.Reserved
+		}
+		endpointPeers = append(endpointPeers, endpointPeer)
+	}
+
+	endpoint := map[string]interface{}{
+		"type":        "wireguard",
+		"tag":         proxy.Name,
+		"address":     address,
+		"private_key": proxy.PrivateKey,
+		"peers":       endpointPeers,
+	}
+	if proxy.MTU > 0 {
+		endpoint["mtu"] = proxy.MTU
+	}
+	return endpoint
+}
+
+// 生成sing-box出站的TLS配置
+func singBoxTLS(proxy ProxyConfig, serverName string) map[string]interface{} {
+	tls := map[string]interface{}{"enabled": true}
+	if serverName != "" {
+		tls["server_name"] = serverName
+	}
+	if proxy.SkipCertVerify {
+		tls["insecure"] = true
+	}
+	if len(proxy.ALPN) > 0 {
+		tls["alpn"] = proxy.ALPN
+	}
+	if proxy.ClientFingerprint != "" {
+		tls["utls"] = map[string]interface{}{
+			"enabled":     true,
+			"fingerprint": proxy.ClientFingerprint,
+		}
+	}
+	if proxy.RealityOpts != nil {
+		tls["reality"] = map[string]interface{}{
+			"enabled":    true,
+			"public_key": proxy.RealityOpts.PublicKey,
+			"short_id":   proxy.RealityOpts.ShortID,
+		}
+	}
+	return tls
+}
+
+// 设置sing-box的V2Ray传输层配置
+func setSingBoxTransport(outbound map[string]interface{}, proxy ProxyConfig) error {
+	switch proxy.Network {
+	case "", "tcp":
+	case "ws":
+		transport := map[string]interface{}{"type": "ws"}
+		if proxy.WSOpts != nil {
+			if proxy.WSOpts.Path != "" {
+				transport["path"] = proxy.WSOpts.Path
+			}
+			if len(proxy.WSOpts.Headers) > 0 {
+				transport["headers"] = proxy.WSOpts.Headers
+			}
+			if proxy.WSOpts.MaxEarlyData > 0 {
+				transport["max_early_data"] = proxy.WSOpts.MaxEarlyData
+				transport["early_data_header_name"] = proxy.WSOpts.EarlyDataHeaderName
+			}
+		}
+		outbound["transport"] = transport
+	case "h2":
+		transport := map[string]interface{}{"type": "http"}
+		if proxy.H2Opts != nil {
+			if len(proxy.H2Opts.Host) > 0 {
+				transport["host"] = proxy.H2Opts.Host
+			}
+			if proxy.H2Opts.Path != "" {
+				transport["path"] = proxy.H2Opts.Path
+			}
+		}
+		outbound["transport"] = transport
+	case "grpc":
+		transport := map[string]interface{}{"type": "grpc"}
+		if proxy.GrpcOpts != nil {
+			transport["service_name"] = proxy.GrpcOpts.GrpcServiceName
+		}
+		outbound["transport"] = transport
+	default:
+		return fmt.Errorf("sing-box不支持的传输方式: %s", proxy.Network)
+	}
+	return nil
+}
+
+// 设置Hysteria系列协议的带宽（Mbps）
+func setSingBoxBandwidth(outbound map[string]interface{}, proxy ProxyConfig) {
+	if up, err := strconv.Atoi(bandwidthMbps(proxy.Up)); err == nil {
+		outbound["up_mbps"] = up
+	}
+	if down, err := strconv.Atoi(bandwidthMbps(proxy.Down)); err == nil {
+		outbound["down_mbps"] = down
+	}
+}
+
+// 将Clash端口跳跃写法（443,5000-6000）转换为sing-box的端口范围列表
+func singBoxPortRanges(ports string) []string {
+	var ranges []string
+	for _, part := range strings.Split(ports, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if strings.Contains(part, "-") {
+			ranges = append(ranges, strings.Replace(part, "-", ":", 1))
+		} else {
+			ranges = append(ranges, part+":"+part)
+		}
+	}
+	return ranges
+}
+
+// 解析sing-box JSON配置，提取其中的代理出站（selector/urltest/direct等出站会被忽略）
+func parseSingBoxConfig(content string) ([]ProxyConfig, error) {
+	var config map[string]interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(content)), &config); err != nil {
+		return nil, fmt.Errorf("解析sing-box配置失败: %v", err)
+	}
+
+	// 新版sing-box的WireGuard写在endpoints中
+	outbounds := append(getMapList(config, "outbounds"), getMapList(config, "endpoints")...)
+	byTag := make(map[string]map[string]interface{})
+	for _, outbound := range outbounds {
+		byTag[getString(outbound, "tag")] = outbound
+	}
+
+	var proxies []ProxyConfig
+	for _, outbound := range outbounds {
+		outboundType := getString(outbound, "type")
+		switch outboundType {
+		case "selector", "urltest", "direct", "block", "dns", "shadowtls":
+			continue
+		}
+
+		proxy, err := parseSingBoxOutbound(outbound, byTag)
+		if err != nil {
+			log.Printf("跳过sing-box出站 %s: %v", getString(outbound, "tag"), err)
+			continue
+		}
+		proxies = append(proxies, proxy)
+	}
+
+	log.Printf("从sing-box配置中提取到 %d 个代理节点", len(proxies))
+	return proxies, nil
+}
+
+// 将单个sing-box出站转换为代理节点，byTag用于查找shadow-tls等detour出站
+func parseSingBoxOutbound(outbound map[string]interface{}, byTag map[string]map[string]interface{}) (ProxyConfig, error) {
+	proxy := ProxyConfig{
+		Name:   getString(outbound, "tag"),
+		Server: getString(outbound, "server"),
+		Port:   getInt(outbound, "server_port"),
+	}
+
+	switch getString(outbound, "type") {
+	case "shadowsocks":
+		proxy.Type = "ss"
+		proxy.Cipher = getString(outbound, "method")
+		proxy.Password = getString(outbound, "password")
+		if plugin := getString(outbound, "plugin"); plugin != "" {
+			pluginStr := plugin
+			if opts := getString(outbound, "plugin_opts"); opts != "" {
+				pluginStr += ";" + opts
+			}
+			proxy.Plugin, proxy.PluginOpts = parseSSPlugin(pluginStr)
+		}
+		// shadow-tls通过detour出站实现，服务器地址以detour出站为准
+		if detour := byTag[getString(outbound, "detour")]; getString(detour, "type") == "shadowtls" {
+			proxy.Server = getString(detour, "server")
+			proxy.Port = getInt(detour, "server_port")
+			proxy.Plugin = "shadow-tls"
+			proxy.PluginOpts = map[string]interface{}{
+				"host":     getString(getMap(detour, "tls"), "server_name"),
+				"password": getString(detour, "password"),
+				"version":  getInt(detour, "version"),
+			}
+		}
+	case "vmess":
+		proxy.Type = "vmess"
+		proxy.UUID = getString(outbound, "uuid")
+		proxy.Cipher = firstNonEmpty(getString(outbound, "security"), "auto")
+		proxy.AlterID = getInt(outbound, "alter_id")
+	case "vless":
+		proxy.Type = "vless"
+		proxy.UUID = getString(outbound, "uuid")
+		proxy.Flow = getString(outbound, "flow")
+	case "trojan":
+		proxy.Type = "trojan"
+		proxy.Password = getString(outbound, "password")
+	case "hysteria2":
+		proxy.Type = "hysteria2"
+		proxy.Password = getString(outbound, "password")
+		if obfs := getMap(outbound, "obfs"); obfs != nil {
+			proxy.Obfs = getString(obfs, "type")
+			proxy.ObfsPassword = getString(obfs, "password")
+		}
+	case "hysteria":
+		proxy.Type = "hysteria"
+		proxy.AuthStr = getString(outbound, "auth_str")
+		proxy.Obfs = getString(outbound, "obfs")
+	case "tuic":
+		proxy.Type = "tuic"
+		proxy.UUID = getString(outbound, "uuid")
+		proxy.Password = getString(outbound, "password")
+		proxy.CongestionController = getString(outbound, "congestion_control")
+		proxy.UDPRelayMode = getString(outbound, "udp_relay_mode")
+	case "anytls":
+		proxy.Type = "anytls"
+		proxy.Password = getString(outbound, "password")
+	case "wireguard":
+		proxy.Type = "wireguard"
+		proxy.UDP = true
+		proxy.PrivateKey = getString(outbound, "private_key")
+		proxy.MTU = getInt(outbound, "mtu")
+		addresses := getStringList(outbound, "local_address")
+		if len(addresses) == 0 {
+			addresses = getStringList(outbound, "address")
+		}
+		for _, address := range addresses {
+			address, _, _ = strings.Cut(address, "/")
+			if strings.Contains(address, ":") {
+				proxy.IPv6 = address
+			} else {
+				proxy.IP = address
+			}
+		}
+		proxy.PublicKey = getString(outbound, "peer_public_key")
+		proxy.PreSharedKey = getString(outbound, "pre_shared_key")
+		proxy.Reserved = singBoxReserved(outbound["reserved"])
+		// 旧版outbound的对端字段为server/server_port，新版endpoint为address/port
+		for _, peer := range getMapList(outbound, "peers") {
+			port := getInt(peer, "server_port")
+			if port == 0 {
+				port = getInt(peer, "port")
+			}
+			proxy.Peers = append(proxy.Peers, WireGuardPeer{
+				Server:       firstNonEmpty(getString(peer, "server"), getString(peer, "address")),
+				Port:         port,
+				PublicKey:    getString(peer, "public_key"),
+				PreSharedKey: getString(peer, "pre_shared_key"),
+				Reserved:     singBoxReserved(peer["reserved"]),
+				AllowedIPs:   getStringList(peer, "allowed_ips"),
+			})
+		}
+		// 只有一个对端时使用顶层字段表示，与URI解析结果保持一致
+		if len(proxy.Peers) == 1 {
+			peer := proxy.Peers[0]
+			proxy.Server, proxy.Port = peer.Server, peer.Port
+			proxy.PublicKey, proxy.PreSharedKey, proxy.Reserved = peer.PublicKey, peer.PreSharedKey, peer.Reserved
+			proxy.Peers = nil
+		}
+	case "socks":
+		proxy.Type = "socks5"
+		proxy.Username = getString(outbound, "username")
+		proxy.Password = getString(outbound, "password")
+	case "http":
+		proxy.Type = "http"
+		proxy.Username = getString(outbound, "username")
+		proxy.Password = getString(outbound, "password")
+	default:
+		return proxy, fmt.Errorf("不支持的sing-box出站类型: %s", getString(outbound, "type"))
+	}
+
+	// 端口跳跃写法 "5000:6000" 转换为Clash的 "5000-6000"
+	if ports := getStringList(outbound, "server_ports"); len(ports) > 0 {
+		proxy.Ports = strings.ReplaceAll(strings.Join(ports, ","), ":", "-")
+		// 只配置了端口范围时，取范围起始端口作为主端口
+		if proxy.Port == 0 {
+			first, _, _ := strings.Cut(ports[0], ":")
+			proxy.Port, _ = strconv.Atoi(first)
+		}
+	}
+	if up := getInt(outbound, "up_mbps"); up > 0 {
+		proxy.Up = strconv.Itoa(up)
+	}
+	if down := getInt(outbound, "down_mbps"); down > 0 {
+		proxy.Down = strconv.Itoa(down)
+	}
+
+	applySingBoxTLS(&proxy, getMap(outbound, "tls"))
+	if err := applySingBoxTransport(&proxy, getMap(outbound, "transport")); err != nil {
+		return proxy, err
+	}
+
+	if proxy.Name == "" {
+		proxy.Name = fmt.Sprintf("%s:%d", proxy.Server, proxy.Port)
+	}
+	return proxy, nil
+}
+
+// 将sing-box出站的TLS配置写入代理节点
+func applySingBoxTLS(proxy *ProxyConfig, tls map[string]interface{}) {
+	if tls == nil || !getBool(tls, "enabled") {
+		return
+	}
+
+	// Clash中VMess/VLESS使用servername，其余协议使用sni
+	serverName := getString(tls, "server_name")
+	switch proxy.Type {
+	case "vmess", "vless":
+		proxy.TLS = true
+		proxy.ServerName = serverName
+	case "http", "socks5":
+		proxy.TLS = true
+		proxy.SNI = serverName
+	default:
+		proxy.SNI = serverName
+	}
+	proxy.SkipCertVerify = getBool(tls, "insecure")
+	proxy.ALPN = getStringList(tls, "alpn")
+	proxy.DisableSNI = getBool(tls, "disable_sni")
+	if utls := getMap(tls, "utls"); getBool(utls, "enabled") {
+		proxy.ClientFingerprint = getString(utls, "fingerprint")
+	}
+	if reality := getMap(tls, "reality"); getBool(reality, "enabled") {
+		proxy.RealityOpts = &RealityOptions{
+			PublicKey: getString(reality, "public_key"),
+			ShortID:   getString(reality, "short_id"),
+		}
+	}
+}
+
+// 将sing-box出站的V2Ray传输层配置写入代理节点
+func applySingBoxTransport(proxy *ProxyConfig, transport map[string]interface{}) error {
+	switch transportType := getString(transport, "type"); transportType {
+	case "":
+	case "ws":
+		proxy.Network = "ws"
+		proxy.WSOpts = &WSOptions{
+			Path:                getString(transport, "path"),
+			MaxEarlyData:        getInt(transport, "max_early_data"),
+			EarlyDataHeaderName: getString(transport, "early_data_header_name"),
+		}
+		if headers := getMap(transport, "headers"); len(headers) > 0 {
+			proxy.WSOpts.Headers = make(map[string]string)
+			for key := range headers {
+				proxy.WSOpts.Headers[key] = strings.Join(getStringList(headers, key), ",")
+			}
+		}
+	case "http":
+		proxy.Network = "h2"
+		proxy.H2Opts = &H2Options{
+			Host: getStringList(transport, "host"),
+			Path: getString(transport, "path"),
+		}
+	case "grpc":
+		proxy.Network = "grpc"
+		proxy.GrpcOpts = &GrpcOptions{GrpcServiceName: getString(transport, "service_name")}
+	default:
+		return fmt.Errorf("不支持的sing-box传输方式: %s", transportType)
+	}
+	return nil
+}
+
+// 解析sing-box的reserved字段，支持 [1,2,3] 和Base64字符串两种写法
+func singBoxReserved(value interface{}) WireGuardReserved {
+	switch v := value.(type) {
+	case string:
+		reserved, _ := parseWireGuardReserved(v)
+		return reserved
+	case []interface{}:
+		var reserved WireGuardReserved
+		for _, item := range v {
+			if b, ok := item.(float64); ok {
+				reserved = append(reserved, int(b))
+			}
+		}
+		return reserved
+	}
+	return nil
+}
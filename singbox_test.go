@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestGenerateSingBoxConfigFormat(t *testing.T) {
+	wireguard := ProxyConfig{
+		Name: "wg", Type: "wireguard", Server: "example.com", Port: 51820, UDP: true,
+		IP: "10.0.0.2", IPv6: "fd00::2", PrivateKey: "cHJpdmF0ZWtleQ==", PublicKey: "cHVibGlja2V5",
+		Reserved: WireGuardReserved{1, 2, 3}, MTU: 1280,
+	}
+	proxies := []ProxyConfig{
+		{Name: "ss", Type: "ss", Server: "1.1.1.1", Port: 8388, Cipher: "aes-128-gcm", Password: "pwd"},
+		wireguard,
+	}
+	content, count, err := generateSingBoxConfig(proxies)
+	if err != nil || count != 2 {
+		t.Fatalf("generateSingBoxConfig() = %d nodes, error %v", count, err)
+	}
+
+	var config map[string]interface{}
+	if err := json.Unmarshal([]byte(content), &config); err != nil {
+		t.Fatal(err)
+	}
+	for _, outbound := range getMapList(config, "outbounds") {
+		if getString(outbound, "type") == "wireguard" {
+			t.Errorf("wireguard written as legacy outbound: %v", outbound)
+		}
+	}
+	endpoints := getMapList(config, "endpoints")
+	if len(endpoints) != 1 || getString(endpoints[0], "tag") != "wg" {
+		t.Errorf("endpoints = %v, want the wireguard node", endpoints)
+	}
+
+	// 1.12起DNS服务器使用type/server，不再使用address和 outbound: any 规则
+	dns := getMap(config, "dns")
+	for _, server := range getMapList(dns, "servers") {
+		if _, legacy := server["address"]; legacy || getString(server, "type") == "" {
+			t.Errorf("legacy DNS server: %v", server)
+		}
+	}
+	for _, rule := range getMapList(dns, "rules") {
+		if _, legacy := rule["outbound"]; legacy {
+			t.Errorf("legacy DNS rule: %v", rule)
+		}
+	}
+
+	parsed, err := parseSingBoxConfig(content)
+	if err != nil || len(parsed) != 2 {
+		t.Fatalf("parseSingBoxConfig() = %d proxies, error %v", len(parsed), err)
+	}
+	if !reflect.DeepEqual(parsed[1], wireguard) {
+		t.Errorf("wireguard round trip:\n got %+v\nwant %+v", parsed[1], wireguard)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// SIP008在线配置结构（Outline等服务端使用）
+type SIP008Config struct {
+	Version        int            `json:"version"`
+	Servers        []SIP008Server `json:"servers"`
+	BytesUsed      int64          `json:"bytes_used,omitempty"`
+	BytesRemaining int64          `json:"bytes_remaining,omitempty"`
+}
+
+// SIP008服务器条目
+type SIP008Server struct {
+	ID         string `json:"id,omitempty"`
+	Remarks    string `json:"remarks,omitempty"`
+	Server     string `json:"server"`
+	ServerPort int    `json:"server_port"`
+	Password   string `json:"password"`
+	Method     string `json:"method"`
+	Plugin     string `json:"plugin,omitempty"`
+	PluginOpts string `json:"plugin_opts,omitempty"`
+}
+
+// 解析SIP008 JSON为Shadowsocks节点
+func parseSIP008(content string) ([]ProxyConfig, error) {
+	var config SIP008Config
+	if err := json.Unmarshal([]byte(strings.TrimSpace(content)), &config); err != nil {
+		return nil, fmt.Errorf("解析SIP008配置失败: %v", err)
+	}
+
+	var proxies []ProxyConfig
+	for i, server := range config.Servers {
+		proxy := ProxyConfig{
+			Type:     "ss",
+			Name:     firstNonEmpty(server.Remarks, fmt.Sprintf("%s:%d", server.Server, server.ServerPort)),
+			Server:   server.Server,
+			Port:     server.ServerPort,
+			Password: server.Password,
+			Cipher:   server.Method,
+		}
+		if server.Plugin != "" {
+			pluginStr := server.Plugin
+			if server.PluginOpts != "" {
+				pluginStr += ";" + server.PluginOpts
+			}
+			proxy.Plugin, proxy.PluginOpts = parseSSPlugin(pluginStr)
+		}
+		if err := validateProxy(proxy); err != nil {
+			log.Printf("跳过第 %d 个无效的SIP008节点 %s: %v", i+1, proxy.Name, err)
+			continue
+		}
+		proxies = append(proxies, proxy)
+	}
+
+	log.Printf("从SIP008配置中提取到 %d 个代理节点", len(proxies))
+	return proxies, nil
+}
+
+// 生成SIP008 JSON，仅包含Shadowsocks节点
+func generateSIP008Config(proxies []ProxyConfig) (string, int, error) {
+	config := SIP008Config{Version: 1, Servers: []SIP008Server{}}
+	for _, proxy := range proxies {
+		if proxy.Type != "ss" {
+			log.Printf("跳过SIP008不支持的节点 %s: 不支持的节点类型 %s", proxy.Name, proxy.Type)
+			continue
+		}
+		server := SIP008Server{
+			ID:         sip008ServerID(proxy),
+			Remarks:    proxy.Name,
+			Server:     proxy.Server,
+			ServerPort: proxy.Port,
+			Password:   proxy.Password,
+			Method:     proxy.Cipher,
+		}
+		if proxy.Plugin != "" {
+			// SIP008中插件名与插件参数分开存放
+			pluginStr := formatSSPlugin(proxy.Plugin, proxy.PluginOpts)
+			server.Plugin, server.PluginOpts, _ = strings.Cut(pluginStr, ";")
+		}
+		config.Servers = append(config.Servers, server)
+	}
+
+	if len(config.Servers) == 0 {
+		return "", 0, fmt.Errorf("未找到任何Shadowsocks节点")
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(config); err != nil {
+		return "", 0, fmt.Errorf("生成SIP008配置失败: %v", err)
+	}
+
+	return buf.String(), len(config.Servers), nil
+}
+
+// 根据节点地址和密码生成稳定的UUID格式ID，保证订阅更新后ID不变
+func sip008ServerID(proxy ProxyConfig) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s:%d:%s:%s", proxy.Server, proxy.Port, proxy.Cipher, proxy.Password)))
+	sum[6] = (sum[6] & 0x0f) | 0x50
+	sum[8] = (sum[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
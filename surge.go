@@ -0,0 +1,648 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Surge支持的节点类型（Clash类型 -> Surge类型），不在表中的节点会被跳过
+var surgeProxyTypes = map[string]string{
+	"ss":        "ss",
+	"vmess":     "vmess",
+	"trojan":    "trojan",
+	"hysteria2": "hysteria2",
+	"tuic":      "tuic-v5",
+	"socks5":    "socks5",
+	"http":      "http",
+}
+
+// Surge支持的规则类型（Clash类型 -> Surge类型）
+var surgeRuleTypes = map[string]string{
+	"DOMAIN":         "DOMAIN",
+	"DOMAIN-SUFFIX":  "DOMAIN-SUFFIX",
+	"DOMAIN-KEYWORD": "DOMAIN-KEYWORD",
+	"IP-CIDR":        "IP-CIDR",
+	"IP-CIDR6":       "IP-CIDR6",
+	"GEOIP":          "GEOIP",
+	"DST-PORT":       "DEST-PORT",
+	"PROCESS-NAME":   "PROCESS-NAME",
+	"RULE-SET":       "RULE-SET",
+	"MATCH":          "FINAL",
+}
+
+// Surge/Loon/QuanX代理组类型（Clash类型 -> 客户端类型）
+var surgeGroupTypes = map[string]string{
+	"select":       "select",
+	"url-test":     "url-test",
+	"fallback":     "fallback",
+	"load-balance": "load-balance",
+}
+
+// 生成Surge配置，代理组与规则沿用generateFullClashConfig的分组模型
+func generateSurgeConfig(proxies []ProxyConfig, groups []ProxyGroup, rules []string, ruleProviders map[string]RuleProvider, managedURL string) (string, int, error) {
+	log.Printf("开始生成Surge配置，节点数量: %d", len(proxies))
+
+	proxyLines, nodeNames := buildClientProxyLines("Surge", proxies, surgeProxyTypes, surgeProxyLine)
+	if len(proxyLines) == 0 {
+		return "", 0, fmt.Errorf("未找到任何Surge支持的代理节点")
+	}
+
+	var sb strings.Builder
+	if managedURL != "" {
+		sb.WriteString(fmt.Sprintf("#!MANAGED-CONFIG %s interval=86400 strict=false\n\n", managedURL))
+	}
+	sb.WriteString("[General]\n")
+	sb.WriteString("loglevel = notify\n")
+	sb.WriteString("dns-server = 223.5.5.5, 119.29.29.29\n")
+	sb.WriteString("skip-proxy = 127.0.0.1, 192.168.0.0/16, 10.0.0.0/8, 172.16.0.0/12, localhost, *.local\n")
+	sb.WriteString("internet-test-url = http://www.gstatic.com/generate_204\n")
+	sb.WriteString("proxy-test-url = http://www.gstatic.com/generate_204\n")
+
+	sb.WriteString("\n[Proxy]\n")
+	for _, line := range proxyLines {
+		sb.WriteString(line + "\n")
+	}
+
+	sb.WriteString("\n[Proxy Group]\n")
+	for _, group := range filterClientGroups(groups, nodeNames) {
+		fields := append([]string{surgeGroupTypes[group.Type]}, group.Proxies...)
+		if group.URL != "" {
+			fields = append(fields, "url="+group.URL)
+		}
+		if group.Interval > 0 {
+			fields = append(fields, "interval="+strconv.Itoa(group.Interval))
+		}
+		sb.WriteString(fmt.Sprintf("%s = %s\n", group.Name, strings.Join(fields, ", ")))
+	}
+
+	sb.WriteString("\n[Rule]\n")
+	clientRules, _ := translateClientRules(rules, ruleProviders, surgeRuleTypes, true, nil)
+	for _, rule := range clientRules {
+		sb.WriteString(rule + "\n")
+	}
+
+	return sb.String(), len(proxyLines), nil
+}
+
+// 生成Surge节点行：名称 = 类型, 服务器, 端口, 参数...
+func surgeProxyLine(proxy ProxyConfig, name string) (string, error) {
+	fields := []string{surgeProxyTypes[proxy.Type], proxy.Server, strconv.Itoa(proxy.Port)}
+	add := func(key, value string) {
+		if value != "" {
+			fields = append(fields, key+"="+quoteClientValue(value))
+		}
+	}
+	addTLS := func(serverName string) {
+		add("sni", serverName)
+		if proxy.SkipCertVerify {
+			add("skip-cert-verify", "true")
+		}
+	}
+	addWS := func() error {
+		switch proxy.Network {
+		case "", "tcp":
+		case "ws":
+			add("ws", "true")
+			if proxy.WSOpts != nil {
+				add("ws-path", proxy.WSOpts.Path)
+				if host := proxy.WSOpts.Headers["Host"]; host != "" {
+					add("ws-headers", "Host:"+host)
+				}
+			}
+		default:
+			return fmt.Errorf("Surge不支持的传输方式: %s", proxy.Network)
+		}
+		return nil
+	}
+
+	switch proxy.Type {
+	case "ss":
+		add("encrypt-method", proxy.Cipher)
+		add("password", proxy.Password)
+		switch proxy.Plugin {
+		case "":
+		case "obfs":
+			add("obfs", getString(proxy.PluginOpts, "mode"))
+			add("obfs-host", getString(proxy.PluginOpts, "host"))
+		case "shadow-tls":
+			add("shadow-tls-password", getString(proxy.PluginOpts, "password"))
+			add("shadow-tls-sni", getString(proxy.PluginOpts, "host"))
+			if version := getInt(proxy.PluginOpts, "version"); version > 0 {
+				add("shadow-tls-version", strconv.Itoa(version))
+			}
+		default:
+			return "", fmt.Errorf("Surge不支持的SS插件: %s", proxy.Plugin)
+		}
+		if proxy.UDP {
+			add("udp-relay", "true")
+		}
+	case "vmess":
+		add("username", proxy.UUID)
+		if err := addWS(); err != nil {
+			return "", err
+		}
+		if proxy.TLS {
+			add("tls", "true")
+			addTLS(proxy.ServerName)
+		}
+		// Surge仅支持alterId为0的VMess AEAD
+		if proxy.AlterID == 0 {
+			add("vmess-aead", "true")
+		}
+	case "trojan":
+		add("password", proxy.Password)
+		if err := addWS(); err != nil {
+			return "", err
+		}
+		addTLS(proxy.SNI)
+	case "hysteria2":
+		if proxy.Obfs != "" {
+			return "", fmt.Errorf("Surge不支持Hysteria2混淆: %s", proxy.Obfs)
+		}
+		add("password", proxy.Password)
+		add("download-bandwidth", bandwidthMbps(proxy.Down))
+		addTLS(proxy.SNI)
+	case "tuic":
+		add("uuid", proxy.UUID)
+		add("password", proxy.Password)
+		if len(proxy.ALPN) > 0 {
+			add("alpn", proxy.ALPN[0])
+		}
+		addTLS(proxy.SNI)
+	case "socks5", "http":
+		if proxy.TLS {
+			if proxy.Type == "socks5" {
+				fields[0] = "socks5-tls"
+			} else {
+				fields[0] = "https"
+			}
+		}
+		// HTTP/SOCKS5的用户名和密码为位置参数
+		if proxy.Username != "" {
+			fields = append(fields, quoteClientValue(proxy.Username), quoteClientValue(proxy.Password))
+		}
+		if proxy.TLS {
+			addTLS(proxy.SNI)
+		}
+	}
+
+	return fmt.Sprintf("%s = %s", name, strings.Join(fields, ", ")), nil
+}
+
+// 按客户端的协议支持表生成节点行，返回节点行及原节点名到输出名称的映射
+func buildClientProxyLines(client string, proxies []ProxyConfig, supported map[string]string, format func(ProxyConfig, string) (string, error)) ([]string, map[string]string) {
+	var lines []string
+	nodeNames := make(map[string]string)
+	for _, proxy := range proxies {
+		if _, ok := supported[proxy.Type]; !ok {
+			log.Printf("跳过%s不支持的节点 %s: 不支持的节点类型 %s", client, proxy.Name, proxy.Type)
+			continue
+		}
+		name := sanitizeClientName(proxy.Name)
+		line, err := format(proxy, name)
+		if err != nil {
+			log.Printf("跳过%s不支持的节点 %s: %v", client, proxy.Name, err)
+			continue
+		}
+		nodeNames[proxy.Name] = name
+		lines = append(lines, line)
+	}
+	return lines, nodeNames
+}
+
+// 逗号和等号是Surge/Loon/QuanX配置行的分隔符，替换为全角字符
+func sanitizeClientName(name string) string {
+	return strings.NewReplacer(",", "，", "=", "＝").Replace(name)
+}
+
+// 包含分隔符或引号的取值需要用双引号包裹
+func quoteClientValue(value string) string {
+	if strings.ContainsAny(value, ",\"") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// 将代理组成员替换为已输出的节点名，移除不支持的节点及因此变为空的代理组
+func filterClientGroups(groups []ProxyGroup, nodeNames map[string]string) []ProxyGroup {
+	groupNames := make(map[string]bool)
+	for _, group := range groups {
+		if _, ok := surgeGroupTypes[group.Type]; ok {
+			groupNames[group.Name] = true
+		}
+	}
+
+	var result []ProxyGroup
+	for _, group := range groups {
+		if !groupNames[group.Name] {
+			log.Printf("跳过不支持的代理组 %s: %s", group.Name, group.Type)
+			continue
+		}
+		var members []string
+		for _, member := range group.Proxies {
+			if name, ok := nodeNames[member]; ok {
+				members = append(members, name)
+			} else if member == "DIRECT" || member == "REJECT" || groupNames[member] {
+				members = append(members, member)
+			}
+		}
+		group.Proxies = members
+		result = append(result, group)
+	}
+
+	// 移除没有成员的代理组，并删除其他代理组对它的引用
+	for {
+		emptyGroups := make(map[string]bool)
+		var kept []ProxyGroup
+		for _, group := range result {
+			if len(group.Proxies) == 0 {
+				emptyGroups[group.Name] = true
+			} else {
+				kept = append(kept, group)
+			}
+		}
+		if len(emptyGroups) == 0 {
+			return kept
+		}
+		for i := range kept {
+			var members []string
+			for _, member := range kept[i].Proxies {
+				if !emptyGroups[member] {
+					members = append(members, member)
+				}
+			}
+			kept[i].Proxies = members
+		}
+		result = kept
+	}
+}
+
+// 客户端的远程规则集（Loon的[Remote Rule]、QuanX的[filter_remote]）
+type clientRuleSet struct {
+	Name     string
+	URL      string
+	Policy   string
+	Interval int
+}
+
+// 将Clash规则转换为客户端规则。RULE-SET按rule-providers换成Surge格式的规则列表链接：
+// ruleTypes中有RULE-SET时直接写入规则，否则作为远程规则集返回；无法转换的规则会被跳过并记录日志
+func translateClientRules(rules []string, providers map[string]RuleProvider, ruleTypes map[string]string, keepOptions bool, policy func(string) string) ([]string, []clientRuleSet) {
+	var result []string
+	var ruleSets []clientRuleSet
+	skipped := 0
+	for _, rule := range rules {
+		parts := strings.Split(rule, ",")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		clashType := strings.ToUpper(parts[0])
+
+		if clashType == "RULE-SET" && len(parts) >= 3 {
+			provider, ok := providers[parts[1]]
+			listURL := provider.clientListURL()
+			if !ok || listURL == "" {
+				skipped++
+				continue
+			}
+			rulePolicy := parts[2]
+			if policy != nil {
+				rulePolicy = policy(rulePolicy)
+			}
+			if ruleType, ok := ruleTypes[clashType]; ok {
+				fields := []string{ruleType, listURL, rulePolicy}
+				if keepOptions {
+					fields = append(fields, parts[3:]...)
+				}
+				result = append(result, strings.Join(fields, ","))
+			} else {
+				ruleSets = append(ruleSets, clientRuleSet{Name: parts[1], URL: listURL, Policy: rulePolicy, Interval: provider.Interval})
+			}
+			continue
+		}
+
+		ruleType, ok := ruleTypes[clashType]
+		if !ok {
+			skipped++
+			continue
+		}
+
+		// MATCH规则没有匹配值，其余规则为 类型,匹配值,策略[,选项]
+		var fields []string
+		policyIndex := 2
+		if clashType == "MATCH" && len(parts) >= 2 {
+			fields = []string{ruleType, parts[1]}
+			policyIndex = 1
+		} else if len(parts) >= 3 {
+			fields = []string{ruleType, parts[1], parts[2]}
+			if keepOptions {
+				fields = append(fields, parts[3:]...)
+			}
+		} else {
+			skipped++
+			continue
+		}
+
+		if policy != nil {
+			fields[policyIndex] = policy(fields[policyIndex])
+		}
+		result = append(result, strings.Join(fields, ","))
+	}
+	if skipped > 0 {
+		log.Printf("跳过 %d 条客户端不支持的规则", skipped)
+	}
+	return result, ruleSets
+}
+
+// Surge/Loon节点行中的类型（小写） -> Clash类型
+var surgeLineTypes = map[string]string{
+	"ss":           "ss",
+	"shadowsocks":  "ss",
+	"shadowsocksr": "ssr",
+	"vmess":        "vmess",
+	"vless":        "vless",
+	"trojan":       "trojan",
+	"hysteria2":    "hysteria2",
+	"tuic":         "tuic",
+	"tuic-v5":      "tuic",
+	"socks5":       "socks5",
+	"socks5-tls":   "socks5",
+	"http":         "http",
+	"https":        "http",
+	"wireguard":    "wireguard",
+}
+
+// 判断是否为Surge/Loon（名称 = 类型, ...）或QuanX（类型=服务器:端口, ...）格式的节点行
+func isProxyLine(line string) bool {
+	key, value, found := strings.Cut(line, "=")
+	if !found {
+		return false
+	}
+	if isQuanXProxyLine(line) {
+		return true
+	}
+	fields := splitProxyLineFields(value)
+	if len(fields) == 0 || strings.TrimSpace(key) == "" {
+		return false
+	}
+	_, ok := surgeLineTypes[strings.ToLower(fields[0])]
+	return ok
+}
+
+// 解析Surge/Loon格式的节点行：名称 = 类型, 服务器, 端口, 位置参数..., key=value...
+// Surge的参数均为key=value，Loon的加密方式、密码等为位置参数，这里统一兼容
+func parseSurgeProxyLine(line string) (ProxyConfig, error) {
+	var proxy ProxyConfig
+	name, value, _ := strings.Cut(line, "=")
+	proxy.Name = strings.TrimSpace(name)
+
+	fields := splitProxyLineFields(value)
+	lineType := strings.ToLower(fields[0])
+	proxy.Type = surgeLineTypes[lineType]
+	if proxy.Type == "" {
+		return proxy, fmt.Errorf("不支持的节点类型: %s", fields[0])
+	}
+
+	// 区分位置参数与key=value参数
+	var positional []string
+	params := make(map[string]string)
+	for _, field := range fields[1:] {
+		if key, val, found := strings.Cut(field, "="); found && !strings.HasPrefix(field, "\"") {
+			params[strings.ToLower(strings.TrimSpace(key))] = unquoteProxyLineValue(val)
+		} else {
+			positional = append(positional, unquoteProxyLineValue(field))
+		}
+	}
+	positionalAt := func(i int) string {
+		if i < len(positional) {
+			return positional[i]
+		}
+		return ""
+	}
+
+	// Loon的WireGuard节点没有服务器和端口位置参数
+	if proxy.Type != "wireguard" {
+		if len(positional) < 2 {
+			return proxy, fmt.Errorf("节点缺少服务器或端口")
+		}
+		proxy.Server = positional[0]
+		port, err := strconv.Atoi(positional[1])
+		if err != nil {
+			return proxy, fmt.Errorf("无效的端口号: %v", err)
+		}
+		proxy.Port = port
+		positional = positional[2:]
+	}
+
+	serverName := firstNonEmpty(params["sni"], params["tls-name"])
+	proxy.SkipCertVerify = isTruthy(params["skip-cert-verify"])
+	tls := isTruthy(params["tls"]) || isTruthy(params["over-tls"])
+
+	// ws传输：Surge为ws=true/ws-path/ws-headers，Loon为transport=ws/path/host
+	setTransport := func() error {
+		switch {
+		case isTruthy(params["ws"]):
+			proxy.Network = "ws"
+			proxy.WSOpts = &WSOptions{Path: params["ws-path"]}
+			if _, host, found := strings.Cut(params["ws-headers"], "Host:"); found {
+				proxy.WSOpts.Headers = map[string]string{"Host": strings.TrimSpace(strings.Split(host, "|")[0])}
+			}
+		case params["transport"] == "ws":
+			proxy.Network = "ws"
+			proxy.WSOpts = &WSOptions{Path: params["path"]}
+			if params["host"] != "" {
+				proxy.WSOpts.Headers = map[string]string{"Host": params["host"]}
+			}
+		case params["transport"] == "http":
+			proxy.Network = "http"
+			proxy.HTTPOpts = &HTTPOptions{}
+			if params["path"] != "" {
+				proxy.HTTPOpts.Path = []string{params["path"]}
+			}
+			if params["host"] != "" {
+				proxy.HTTPOpts.Headers = map[string][]string{"Host": {params["host"]}}
+			}
+		case params["transport"] == "" || params["transport"] == "tcp":
+		default:
+			return fmt.Errorf("不支持的传输方式: %s", params["transport"])
+		}
+		return nil
+	}
+
+	switch proxy.Type {
+	case "ss":
+		proxy.Cipher = firstNonEmpty(params["encrypt-method"], positionalAt(0))
+		proxy.Password = firstNonEmpty(params["password"], positionalAt(1))
+		if obfs := firstNonEmpty(params["obfs"], params["obfs-name"]); obfs != "" {
+			proxy.Plugin = "obfs"
+			proxy.PluginOpts = map[string]interface{}{"mode": obfs}
+			if host := params["obfs-host"]; host != "" {
+				proxy.PluginOpts["host"] = host
+			}
+		}
+		if password := params["shadow-tls-password"]; password != "" {
+			proxy.Plugin = "shadow-tls"
+			proxy.PluginOpts = map[string]interface{}{
+				"host":     params["shadow-tls-sni"],
+				"password": password,
+			}
+			if version, err := strconv.Atoi(params["shadow-tls-version"]); err == nil {
+				proxy.PluginOpts["version"] = version
+			}
+		}
+		proxy.UDP = isTruthy(params["udp-relay"]) || isTruthy(params["udp"])
+	case "ssr":
+		proxy.Cipher = positionalAt(0)
+		proxy.Password = positionalAt(1)
+		proxy.Protocol = params["protocol"]
+		proxy.ProtocolParam = params["protocol-param"]
+		proxy.Obfs = params["obfs"]
+		proxy.ObfsParam = params["obfs-param"]
+	case "vmess":
+		// Surge: username=uuid；Loon: 加密方式,"uuid"
+		if params["username"] != "" {
+			proxy.UUID = params["username"]
+			proxy.Cipher = "auto"
+		} else {
+			proxy.Cipher = firstNonEmpty(positionalAt(0), "auto")
+			proxy.UUID = positionalAt(1)
+		}
+		proxy.AlterID, _ = strconv.Atoi(params["alterid"])
+		if err := setTransport(); err != nil {
+			return proxy, err
+		}
+		proxy.TLS = tls
+		proxy.ServerName = serverName
+	case "vless":
+		proxy.UUID = firstNonEmpty(params["username"], positionalAt(0))
+		proxy.Flow = params["flow"]
+		if err := setTransport(); err != nil {
+			return proxy, err
+		}
+		proxy.TLS = tls
+		proxy.ServerName = serverName
+		if publicKey := params["public-key"]; publicKey != "" {
+			proxy.TLS = true
+			proxy.RealityOpts = &RealityOptions{PublicKey: publicKey, ShortID: params["short-id"]}
+		}
+	case "trojan":
+		proxy.Password = firstNonEmpty(params["password"], positionalAt(0))
+		if err := setTransport(); err != nil {
+			return proxy, err
+		}
+		proxy.SNI = serverName
+	case "hysteria2":
+		proxy.Password = firstNonEmpty(params["password"], positionalAt(0))
+		proxy.SNI = serverName
+		proxy.Down = params["download-bandwidth"]
+		if obfsPassword := params["salamander-password"]; obfsPassword != "" {
+			proxy.Obfs = "salamander"
+			proxy.ObfsPassword = obfsPassword
+		}
+	case "tuic":
+		proxy.UUID = params["uuid"]
+		proxy.Password = params["password"]
+		if proxy.UUID == "" {
+			return proxy, fmt.Errorf("仅支持TUIC v5节点")
+		}
+		if alpn := params["alpn"]; alpn != "" {
+			proxy.ALPN = strings.Split(alpn, ",")
+		}
+		proxy.SNI = serverName
+	case "socks5", "http":
+		proxy.Username = firstNonEmpty(params["username"], positionalAt(0))
+		proxy.Password = firstNonEmpty(params["password"], positionalAt(1))
+		proxy.TLS = tls || lineType == "socks5-tls" || lineType == "https"
+		proxy.SNI = serverName
+	case "wireguard":
+		if params["section-name"] != "" {
+			return proxy, fmt.Errorf("不支持引用[WireGuard]段落的Surge节点")
+		}
+		proxy.UDP = true
+		proxy.IP = params["interface-ip"]
+		proxy.IPv6 = params["interface-ipv6"]
+		proxy.PrivateKey = params["private-key"]
+		proxy.MTU, _ = strconv.Atoi(params["mtu"])
+		peers := strings.TrimSuffix(strings.TrimPrefix(params["peers"], "["), "]")
+		for _, peerStr := range splitProxyLineFields(peers) {
+			peerParams := make(map[string]string)
+			for _, field := range splitProxyLineFields(strings.Trim(peerStr, "{}")) {
+				key, val, _ := strings.Cut(field, "=")
+				peerParams[strings.TrimSpace(key)] = unquoteProxyLineValue(val)
+			}
+			host, portStr, err := net.SplitHostPort(peerParams["endpoint"])
+			if err != nil {
+				return proxy, fmt.Errorf("无效的WireGuard endpoint: %v", err)
+			}
+			port, _ := strconv.Atoi(portStr)
+			peer := WireGuardPeer{
+				Server:       host,
+				Port:         port,
+				PublicKey:    peerParams["public-key"],
+				PreSharedKey: peerParams["preshared-key"],
+			}
+			if allowedIPs := peerParams["allowed-ips"]; allowedIPs != "" {
+				peer.AllowedIPs = strings.Split(allowedIPs, ",")
+			}
+			if reserved := strings.Trim(peerParams["reserved"], "[]"); reserved != "" {
+				peer.Reserved, _ = parseWireGuardReserved(reserved)
+			}
+			proxy.Peers = append(proxy.Peers, peer)
+		}
+		if len(proxy.Peers) == 0 {
+			return proxy, fmt.Errorf("WireGuard节点缺少peers")
+		}
+		// 只有一个对端时使用顶层字段表示，与URI解析结果保持一致
+		if len(proxy.Peers) == 1 {
+			peer := proxy.Peers[0]
+			proxy.Server, proxy.Port = peer.Server, peer.Port
+			proxy.PublicKey, proxy.PreSharedKey, proxy.Reserved = peer.PublicKey, peer.PreSharedKey, peer.Reserved
+			proxy.Peers = nil
+		}
+	}
+
+	if proxy.Name == "" {
+		proxy.Name = fmt.Sprintf("%s:%d", proxy.Server, proxy.Port)
+	}
+	return proxy, nil
+}
+
+// 按逗号拆分节点行参数，忽略引号、方括号和花括号内的逗号
+func splitProxyLineFields(value string) []string {
+	var fields []string
+	var current strings.Builder
+	depth := 0
+	inQuote := false
+	for _, r := range value {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+		case inQuote:
+		case r == '[' || r == '{':
+			depth++
+		case r == ']' || r == '}':
+			depth--
+		case r == ',' && depth == 0:
+			fields = append(fields, strings.TrimSpace(current.String()))
+			current.Reset()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	if field := strings.TrimSpace(current.String()); field != "" || len(fields) > 0 {
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// 去掉节点行参数值两侧的空白和引号
+func unquoteProxyLineValue(value string) string {
+	value = strings.TrimSpace(value)
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		return unquoted
+	}
+	return strings.Trim(value, "\"")
+}
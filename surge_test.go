@@ -0,0 +1,39 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTranslateClientRules(t *testing.T) {
+	providers := map[string]RuleProvider{
+		"list": {Type: "http", Behavior: "classical", Format: "text", URL: "https://example.com/list.list", Interval: 3600},
+		"yaml": {Type: "http", Behavior: "domain", Format: "yaml", URL: "https://example.com/domain.yaml"},
+	}
+	rules := []string{
+		"RULE-SET,list,Proxy",
+		"RULE-SET,yaml,Proxy",
+		"RULE-SET,missing,Proxy",
+		"IP-CIDR,10.0.0.0/8,DIRECT,no-resolve",
+		"PROCESS-NAME,curl,DIRECT",
+		"MATCH,Final",
+	}
+
+	surgeRules, surgeSets := translateClientRules(rules, providers, surgeRuleTypes, true, nil)
+	wantSurge := []string{
+		"RULE-SET,https://example.com/list.list,Proxy",
+		"IP-CIDR,10.0.0.0/8,DIRECT,no-resolve",
+		"PROCESS-NAME,curl,DIRECT",
+		"FINAL,Final",
+	}
+	if !reflect.DeepEqual(surgeRules, wantSurge) || len(surgeSets) != 0 {
+		t.Errorf("Surge rules = %q, rule sets = %+v", surgeRules, surgeSets)
+	}
+
+	quanXRules, quanXSets := translateClientRules(rules, providers, quanXRuleTypes, false, quanXPolicy)
+	wantQuanX := []string{"ip-cidr,10.0.0.0/8,direct", "final,Final"}
+	wantSets := []clientRuleSet{{Name: "list", URL: "https://example.com/list.list", Policy: "Proxy", Interval: 3600}}
+	if !reflect.DeepEqual(quanXRules, wantQuanX) || !reflect.DeepEqual(quanXSets, wantSets) {
+		t.Errorf("QuanX rules = %q, rule sets = %+v", quanXRules, quanXSets)
+	}
+}
@@ -103,7 +103,7 @@ func getIndexTemplate() string {
             height: 18px;
         }
         
-        input[type="url"], textarea {
+        input[type="url"], input[type="text"].filter-input, textarea {
             width: 100%;
             padding: 15px;
             border: 2px solid #e1e5e9;
@@ -112,11 +112,15 @@ func getIndexTemplate() string {
             transition: border-color 0.3s ease;
         }
         
-        input[type="url"]:focus, textarea:focus {
+        input[type="url"]:focus, input[type="text"].filter-input:focus, textarea:focus {
             outline: none;
             border-color: #667eea;
         }
         
+        .filter-input {
+            margin-bottom: 10px;
+        }
+        
         textarea {
             min-height: 200px;
             resize: vertical;
@@ -273,10 +277,41 @@ func getIndexTemplate() string {
             <div class="config-input" id="text_input">
                 <div class="form-group">
                     <label for="config_text" id="text_label">配置文件/订阅内容：</label>
-                    <textarea id="config_text" name="config_text" placeholder="请粘贴 Clash YAML 配置、订阅内容或Base64编码的订阅..."></textarea>
+                    <textarea id="config_text" name="config_text" placeholder="请粘贴 Clash YAML、sing-box/Xray/SIP008 JSON 配置、订阅内容或Base64编码的订阅..."></textarea>
+                </div>
+            </div>
+
+            <div class="form-group">
+                <label>节点过滤（可选）：</label>
+                <input type="text" class="filter-input" id="filter_include" name="filter_include" placeholder="保留名称匹配的节点（正则），如 香港|HK|日本">
+                <input type="text" class="filter-input" id="filter_exclude" name="filter_exclude" placeholder="剔除名称匹配的节点（正则），如 剩余流量|到期|官网">
+                <input type="text" class="filter-input" id="filter_types" name="filter_types" placeholder="保留的协议类型（逗号分隔），如 ss,vmess,trojan">
+                <input type="text" class="filter-input" id="filter_ports" name="filter_ports" placeholder="保留的端口或范围（逗号分隔），如 443,8000-9000">
+            </div>
+
+            <div class="form-group">
+                <label for="rename_rules">节点重命名（可选，每行一条，按顺序应用）：</label>
+                <textarea id="rename_rules" name="rename_rules" style="min-height: 100px;" placeholder="正则替换：^\[.*?\]\s* =&gt; &#10;名称模板：{region}-{index} [{type}]&#10;模板可用变量：{name} {type} {server} {port} {region} {flag} {index}"></textarea>
+                <div class="radio-group">
+                    <input type="checkbox" id="flag_emoji" name="flag_emoji">
+                    <label for="flag_emoji" style="margin-bottom: 0;">按节点地区在名称前添加旗帜（如 🇭🇰）</label>
                 </div>
             </div>
 
+            <div class="form-group">
+                <label for="region_groups">地区分组（可选）：</label>
+                <select id="region_groups" name="region_groups" style="padding: 10px; border: 2px solid #e1e5e9; border-radius: 10px; font-size: 16px;">
+                    <option value="">不生成地区分组</option>
+                    <option value="url-test">按地区生成自动测速组（url-test）</option>
+                    <option value="fallback">按地区生成故障转移组（fallback）</option>
+                </select>
+            </div>
+
+            <div class="form-group">
+                <label for="template">配置模板（可选）：</label>
+                <input type="text" class="filter-input" id="template" name="template" placeholder="ACL4SSR等subconverter格式的INI模板链接，或templates目录下的文件名">
+            </div>
+
             <button type="submit" id="convertBtn">🎯 开始转换</button>
         </form>
         
@@ -314,13 +349,13 @@ func getIndexTemplate() string {
                     urlLabel.textContent = '配置文件/订阅 URL：';
                     textLabel.textContent = '配置文件/订阅内容：';
                     urlInput.placeholder = 'https://example.com/config.yaml 或订阅链接';
-                    textInput.placeholder = '请粘贴 Clash YAML 配置、订阅内容或Base64编码的订阅...';
+                    textInput.placeholder = '请粘贴 Clash YAML、sing-box/Xray/SIP008 JSON 配置、订阅内容或Base64编码的订阅...';
                     convertBtn.textContent = '🎯 生成订阅链接';
                 } else {
                     urlLabel.textContent = '订阅链接 URL：';
                     textLabel.textContent = '订阅内容：';
                     urlInput.placeholder = 'https://example.com/subscription 或机场订阅链接';
-                    textInput.placeholder = '请粘贴订阅内容或Base64编码的订阅...';
+                    textInput.placeholder = '请粘贴订阅内容、Surge/Loon/QuanX 节点列表、sing-box/Xray JSON 配置或Base64编码的订阅...';
                     convertBtn.textContent = '🔄 转为Clash配置';
                 }
             });
@@ -333,6 +368,29 @@ func getIndexTemplate() string {
             const formData = new FormData(this);
             const data = Object.fromEntries(formData.entries());
 
+            // 节点过滤条件随订阅保存
+            const splitList = value => (value || '').split(',').map(item => item.trim()).filter(item => item);
+            data.filter = {
+                include: data.filter_include || '',
+                exclude: data.filter_exclude || '',
+                types: splitList(data.filter_types),
+                ports: splitList(data.filter_ports)
+            };
+            delete data.filter_include;
+            delete data.filter_exclude;
+            delete data.filter_types;
+            delete data.filter_ports;
+            data.flag_emoji = formData.has('flag_emoji');
+
+            // 重命名规则：含 "=>" 的行为正则替换，其余行为名称模板
+            data.rename_rules = (data.rename_rules || '').split('\n').filter(line => line.trim()).map(line => {
+                const index = line.indexOf('=>');
+                if (index < 0) {
+                    return { template: line.trim() };
+                }
+                return { pattern: line.slice(0, index).trim(), replace: line.slice(index + 2).trim() };
+            });
+
             // 显示加载状态
             document.getElementById('loading').style.display = 'block';
             document.getElementById('result').style.display = 'none';
@@ -403,11 +461,24 @@ func getIndexTemplate() string {
                                 <span id="sub-url">${result.subscription_url}</span>
                                 <button class="copy-btn" onclick="copyToClipboard('sub-url')">📋 复制链接</button>
                             </div>
+                            <div class="subscription-url">
+                                <strong>sing-box配置链接：</strong><br>
+                                <span id="singbox-url">${result.singbox_url}</span>
+                                <button class="copy-btn" onclick="copyToClipboard('singbox-url')">📋 复制链接</button>
+                            </div>
+                            <div class="subscription-url">
+                                <strong>Xray配置链接：</strong><br>
+                                <span id="xray-url">${result.xray_url}</span>
+                                <button class="copy-btn" onclick="copyToClipboard('xray-url')">📋 复制链接</button>
+                            </div>
                             ${isAutoUpdate ? '<div style="background: #fff3cd; border: 1px solid #ffeaa7; border-radius: 5px; padding: 10px; margin: 10px 0; color: #856404;"><strong>🔄 实时更新：</strong> 此订阅链接每次访问时都会检查并获取最新内容</div>' : ''}
                             <p><strong>使用说明：</strong></p>
                             <ul>
                                 <li>将上面的订阅链接复制到你的代理客户端中</li>
                                 <li>支持 PassWall、V2rayN、Clash 等客户端</li>
+                                <li>Surge、Loon、Quantumult X 用户可在订阅链接后添加 <code>?target=surge</code>、<code>?target=loon</code> 或 <code>?target=quanx</code></li>
+                                <li>纯 Shadowsocks 订阅可添加 <code>?target=sip008</code> 获取 SIP008 JSON（Outline 等客户端）</li>
+                                <li>添加 <code>?target=clash-proxies</code> 可作为 mihomo 的 proxy-providers 数据源，<code>?target=clash-provider</code> 获取通过 proxy-providers 引用节点的完整配置；均支持 <code>include</code>、<code>exclude</code>、<code>type</code>、<code>port</code> 过滤参数</li>
                                 <li>每个配置都有独立的订阅链接，不会相互干扰</li>
                                 ${isAutoUpdate ? '<li>URL来源的配置会实时更新，每次访问都获取最新节点</li>' : '<li>文本输入的配置不会自动更新</li>'}
                             </ul>
@@ -0,0 +1,478 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Xray完整配置结构
+type XrayConfig struct {
+	Log         map[string]interface{}   `json:"log"`
+	DNS         map[string]interface{}   `json:"dns"`
+	Inbounds    []map[string]interface{} `json:"inbounds"`
+	Outbounds   []map[string]interface{} `json:"outbounds"`
+	Observatory map[string]interface{}   `json:"observatory"`
+	Routing     map[string]interface{}   `json:"routing"`
+}
+
+// Xray中的负载均衡器及内置出站标签
+const (
+	xrayBalancerTag = "balancer"
+	xrayDirectTag   = "direct"
+	xrayBlockTag    = "block"
+
+	// 节点出站标签的前缀。Xray按前缀匹配observatory和balancer的选择器，
+	// 统一前缀后只需选择该前缀，节点名称互为前缀时也不会误选
+	xrayProxyTagPrefix = "proxy-"
+)
+
+// 生成完整的Xray配置（与generateFullClashConfig对应），节点通过负载均衡器按延迟选择
+func generateXrayConfig(proxies []ProxyConfig) (string, int, error) {
+	log.Printf("开始生成Xray配置，节点数量: %d", len(proxies))
+
+	var outbounds []map[string]interface{}
+	for _, proxy := range proxies {
+		outbound, err := xrayOutbound(proxy)
+		if err != nil {
+			log.Printf("跳过Xray不支持的节点 %s: %v", proxy.Name, err)
+			continue
+		}
+		outbounds = append(outbounds, outbound)
+	}
+	nodeCount := len(outbounds)
+
+	if nodeCount == 0 {
+		return "", 0, fmt.Errorf("未找到任何Xray支持的代理节点")
+	}
+
+	outbounds = append(outbounds,
+		map[string]interface{}{"tag": xrayDirectTag, "protocol": "freedom"},
+		map[string]interface{}{"tag": xrayBlockTag, "protocol": "blackhole"},
+	)
+
+	config := XrayConfig{
+		Log: map[string]interface{}{
+			"loglevel": "warning",
+		},
+		DNS: map[string]interface{}{
+			"servers": []interface{}{
+				"https://dns.google/dns-query",
+				map[string]interface{}{
+					"address": "223.5.5.5",
+					"domains": []string{"geosite:cn"},
+				},
+			},
+		},
+		Inbounds: []map[string]interface{}{
+			{
+				"tag":      "socks-in",
+				"protocol": "socks",
+				"listen":   "127.0.0.1",
+				"port":     10808,
+				"settings": map[string]interface{}{"udp": true},
+				"sniffing": map[string]interface{}{
+					"enabled":      true,
+					"destOverride": []string{"http", "tls", "quic"},
+				},
+			},
+			{
+				"tag":      "http-in",
+				"protocol": "http",
+				"listen":   "127.0.0.1",
+				"port":     10809,
+			},
+		},
+		Outbounds: outbounds,
+		Observatory: map[string]interface{}{
+			"subjectSelector": []string{xrayProxyTagPrefix},
+			"probeURL":        "http://www.gstatic.com/generate_204",
+			"probeInterval":   "5m",
+		},
+		Routing: map[string]interface{}{
+			"domainStrategy": "IPIfNonMatch",
+			"balancers": []map[string]interface{}{
+				{
+					"tag":      xrayBalancerTag,
+					"selector": []string{xrayProxyTagPrefix},
+					"strategy": map[string]interface{}{"type": "leastPing"},
+				},
+			},
+			"rules": []map[string]interface{}{
+				{"type": "field", "domain": []string{"geosite:category-ads-all"}, "outboundTag": xrayBlockTag},
+				{"type": "field", "ip": []string{"geoip:private"}, "outboundTag": xrayDirectTag},
+				{"type": "field", "domain": []string{"geosite:cn"}, "outboundTag": xrayDirectTag},
+				{"type": "field", "ip": []string{"geoip:cn"}, "outboundTag": xrayDirectTag},
+				{"type": "field", "network": "tcp,udp", "balancerTag": xrayBalancerTag},
+			},
+		},
+	}
+
+	// 不转义HTML字符，保持节点名称的可读性
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(config); err != nil {
+		return "", 0, fmt.Errorf("生成Xray配置失败: %v", err)
+	}
+
+	return buf.String(), nodeCount, nil
+}
+
+// 将代理节点转换为Xray出站
+func xrayOutbound(proxy ProxyConfig) (map[string]interface{}, error) {
+	outbound := map[string]interface{}{"tag": xrayProxyTagPrefix + proxy.Name}
+
+	switch proxy.Type {
+	case "vmess":
+		outbound["protocol"] = "vmess"
+		outbound["settings"] = map[string]interface{}{
+			"vnext": []map[string]interface{}{{
+				"address": proxy.Server,
+				"port":    proxy.Port,
+				"users": []map[string]interface{}{{
+					"id":       proxy.UUID,
+					"alterId":  proxy.AlterID,
+					"security": firstNonEmpty(proxy.Cipher, "auto"),
+				}},
+			}},
+		}
+	case "vless":
+		user := map[string]interface{}{
+			"id":         proxy.UUID,
+			"encryption": "none",
+		}
+		if proxy.Flow != "" {
+			user["flow"] = proxy.Flow
+		}
+		outbound["protocol"] = "vless"
+		outbound["settings"] = map[string]interface{}{
+			"vnext": []map[string]interface{}{{
+				"address": proxy.Server,
+				"port":    proxy.Port,
+				"users":   []map[string]interface{}{user},
+			}},
+		}
+	case "trojan":
+		outbound["protocol"] = "trojan"
+		outbound["settings"] = map[string]interface{}{
+			"servers": []map[string]interface{}{{
+				"address":  proxy.Server,
+				"port":     proxy.Port,
+				"password": proxy.Password,
+			}},
+		}
+	case "ss":
+		if proxy.Plugin != "" {
+			return nil, fmt.Errorf("Xray不支持SS插件: %s", proxy.Plugin)
+		}
+		outbound["protocol"] = "shadowsocks"
+		outbound["settings"] = map[string]interface{}{
+			"servers": []map[string]interface{}{{
+				"address":  proxy.Server,
+				"port":     proxy.Port,
+				"method":   proxy.Cipher,
+				"password": proxy.Password,
+			}},
+		}
+	case "socks5", "http":
+		server := map[string]interface{}{
+			"address": proxy.Server,
+			"port":    proxy.Port,
+		}
+		if proxy.Username != "" {
+			server["users"] = []map[string]interface{}{{
+				"user": proxy.Username,
+				"pass": proxy.Password,
+			}}
+		}
+		outbound["protocol"] = "http"
+		if proxy.Type == "socks5" {
+			outbound["protocol"] = "socks"
+		}
+		outbound["settings"] = map[string]interface{}{
+			"servers": []map[string]interface{}{server},
+		}
+	default:
+		return nil, fmt.Errorf("Xray不支持的节点类型: %s", proxy.Type)
+	}
+
+	streamSettings, err := xrayStreamSettings(proxy)
+	if err != nil {
+		return nil, err
+	}
+	outbound["streamSettings"] = streamSettings
+	return outbound, nil
+}
+
+// 生成Xray出站的传输层及TLS/REALITY配置
+func xrayStreamSettings(proxy ProxyConfig) (map[string]interface{}, error) {
+	stream := map[string]interface{}{}
+
+	switch proxy.Network {
+	case "", "tcp":
+		stream["network"] = "tcp"
+	case "ws":
+		stream["network"] = "ws"
+		ws := map[string]interface{}{}
+		if proxy.WSOpts != nil {
+			if proxy.WSOpts.Path != "" {
+				ws["path"] = proxy.WSOpts.Path
+			}
+			if len(proxy.WSOpts.Headers) > 0 {
+				ws["headers"] = proxy.WSOpts.Headers
+			}
+		}
+		stream["wsSettings"] = ws
+	case "h2":
+		stream["network"] = "h2"
+		h2 := map[string]interface{}{}
+		if proxy.H2Opts != nil {
+			if len(proxy.H2Opts.Host) > 0 {
+				h2["host"] = proxy.H2Opts.Host
+			}
+			if proxy.H2Opts.Path != "" {
+				h2["path"] = proxy.H2Opts.Path
+			}
+		}
+		stream["httpSettings"] = h2
+	case "grpc":
+		stream["network"] = "grpc"
+		grpc := map[string]interface{}{}
+		if proxy.GrpcOpts != nil {
+			grpc["serviceName"] = proxy.GrpcOpts.GrpcServiceName
+		}
+		stream["grpcSettings"] = grpc
+	case "http":
+		// Clash的http传输对应Xray TCP的HTTP伪装头
+		request := map[string]interface{}{}
+		if proxy.HTTPOpts != nil {
+			if proxy.HTTPOpts.Method != "" {
+				request["method"] = proxy.HTTPOpts.Method
+			}
+			if len(proxy.HTTPOpts.Path) > 0 {
+				request["path"] = proxy.HTTPOpts.Path
+			}
+			if len(proxy.HTTPOpts.Headers) > 0 {
+				request["headers"] = proxy.HTTPOpts.Headers
+			}
+		}
+		stream["network"] = "tcp"
+		stream["tcpSettings"] = map[string]interface{}{
+			"header": map[string]interface{}{"type": "http", "request": request},
+		}
+	default:
+		return nil, fmt.Errorf("Xray不支持的传输方式: %s", proxy.Network)
+	}
+
+	// Trojan始终使用TLS，VMess/VLESS/HTTP/SOCKS5由tls字段决定
+	serverName := proxy.ServerName
+	useTLS := proxy.TLS
+	if proxy.Type == "trojan" {
+		serverName = proxy.SNI
+		useTLS = true
+	} else if proxy.Type == "socks5" || proxy.Type == "http" {
+		serverName = proxy.SNI
+	}
+
+	switch {
+	case proxy.RealityOpts != nil:
+		stream["security"] = "reality"
+		stream["realitySettings"] = map[string]interface{}{
+			"serverName":  serverName,
+			"fingerprint": firstNonEmpty(proxy.ClientFingerprint, "chrome"),
+			"publicKey":   proxy.RealityOpts.PublicKey,
+			"shortId":     proxy.RealityOpts.ShortID,
+		}
+	case useTLS:
+		tls := map[string]interface{}{}
+		if serverName != "" {
+			tls["serverName"] = serverName
+		}
+		if proxy.SkipCertVerify {
+			tls["allowInsecure"] = true
+		}
+		if len(proxy.ALPN) > 0 {
+			tls["alpn"] = proxy.ALPN
+		}
+		if proxy.ClientFingerprint != "" {
+			tls["fingerprint"] = strings.ToLower(proxy.ClientFingerprint)
+		}
+		stream["security"] = "tls"
+		stream["tlsSettings"] = tls
+	default:
+		stream["security"] = "none"
+	}
+
+	return stream, nil
+}
+
+// 解析Xray/V2Ray JSON配置，提取其中的代理出站（freedom/blackhole等出站会被忽略）
+func parseXrayConfig(content string) ([]ProxyConfig, error) {
+	var config map[string]interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(content)), &config); err != nil {
+		return nil, fmt.Errorf("解析Xray配置失败: %v", err)
+	}
+
+	var proxies []ProxyConfig
+	for _, outbound := range getMapList(config, "outbounds") {
+		switch getString(outbound, "protocol") {
+		case "freedom", "blackhole", "dns", "loopback":
+			continue
+		}
+
+		proxy, err := parseXrayOutbound(outbound)
+		if err != nil {
+			log.Printf("跳过Xray出站 %s: %v", getString(outbound, "tag"), err)
+			continue
+		}
+		proxies = append(proxies, proxy)
+	}
+
+	log.Printf("从Xray配置中提取到 %d 个代理节点", len(proxies))
+	return proxies, nil
+}
+
+// 将单个Xray出站转换为代理节点
+func parseXrayOutbound(outbound map[string]interface{}) (ProxyConfig, error) {
+	proxy := ProxyConfig{Name: strings.TrimPrefix(getString(outbound, "tag"), xrayProxyTagPrefix)}
+	settings := getMap(outbound, "settings")
+
+	// vmess/vless使用vnext，其余协议使用servers，均只取第一个服务器和用户
+	var server, user map[string]interface{}
+	if vnext := getMapList(settings, "vnext"); len(vnext) > 0 {
+		server = vnext[0]
+	} else if servers := getMapList(settings, "servers"); len(servers) > 0 {
+		server = servers[0]
+	} else {
+		return proxy, fmt.Errorf("出站缺少服务器配置")
+	}
+	if users := getMapList(server, "users"); len(users) > 0 {
+		user = users[0]
+	}
+	proxy.Server = getString(server, "address")
+	proxy.Port = getInt(server, "port")
+
+	switch protocol := getString(outbound, "protocol"); protocol {
+	case "vmess":
+		proxy.Type = "vmess"
+		proxy.UUID = getString(user, "id")
+		proxy.AlterID = getInt(user, "alterId")
+		proxy.Cipher = firstNonEmpty(getString(user, "security"), "auto")
+	case "vless":
+		proxy.Type = "vless"
+		proxy.UUID = getString(user, "id")
+		proxy.Flow = getString(user, "flow")
+	case "trojan":
+		proxy.Type = "trojan"
+		proxy.Password = getString(server, "password")
+	case "shadowsocks":
+		proxy.Type = "ss"
+		proxy.Cipher = getString(server, "method")
+		proxy.Password = getString(server, "password")
+	case "socks", "http":
+		proxy.Type = "http"
+		if protocol == "socks" {
+			proxy.Type = "socks5"
+		}
+		proxy.Username = getString(user, "user")
+		proxy.Password = getString(user, "pass")
+	default:
+		return proxy, fmt.Errorf("不支持的Xray出站协议: %s", protocol)
+	}
+
+	if err := applyXrayStreamSettings(&proxy, getMap(outbound, "streamSettings")); err != nil {
+		return proxy, err
+	}
+
+	if proxy.Name == "" {
+		proxy.Name = fmt.Sprintf("%s:%d", proxy.Server, proxy.Port)
+	}
+	return proxy, nil
+}
+
+// 将Xray的streamSettings写入代理节点
+func applyXrayStreamSettings(proxy *ProxyConfig, stream map[string]interface{}) error {
+	switch network := getString(stream, "network"); network {
+	case "", "tcp", "raw":
+		header := getMap(getMap(stream, "tcpSettings"), "header")
+		if getString(header, "type") == "http" {
+			request := getMap(header, "request")
+			proxy.Network = "http"
+			proxy.HTTPOpts = &HTTPOptions{
+				Method: getString(request, "method"),
+				Path:   getStringList(request, "path"),
+			}
+			if headers := getMap(request, "headers"); len(headers) > 0 {
+				proxy.HTTPOpts.Headers = make(map[string][]string)
+				for key := range headers {
+					proxy.HTTPOpts.Headers[key] = getStringList(headers, key)
+				}
+			}
+		}
+	case "ws":
+		ws := getMap(stream, "wsSettings")
+		proxy.Network = "ws"
+		proxy.WSOpts = &WSOptions{Path: getString(ws, "path")}
+		if headers := getMap(ws, "headers"); len(headers) > 0 {
+			proxy.WSOpts.Headers = make(map[string]string)
+			for key := range headers {
+				proxy.WSOpts.Headers[key] = getString(headers, key)
+			}
+		}
+		// 新版Xray的host独立于headers
+		if host := getString(ws, "host"); host != "" {
+			if proxy.WSOpts.Headers == nil {
+				proxy.WSOpts.Headers = make(map[string]string)
+			}
+			proxy.WSOpts.Headers["Host"] = host
+		}
+	case "h2", "http":
+		h2 := getMap(stream, "httpSettings")
+		proxy.Network = "h2"
+		proxy.H2Opts = &H2Options{
+			Host: getStringList(h2, "host"),
+			Path: getString(h2, "path"),
+		}
+	case "grpc":
+		proxy.Network = "grpc"
+		proxy.GrpcOpts = &GrpcOptions{GrpcServiceName: getString(getMap(stream, "grpcSettings"), "serviceName")}
+	default:
+		return fmt.Errorf("不支持的Xray传输方式: %s", network)
+	}
+
+	var tls map[string]interface{}
+	switch security := getString(stream, "security"); security {
+	case "", "none":
+		return nil
+	case "tls":
+		tls = getMap(stream, "tlsSettings")
+		proxy.SkipCertVerify = getBool(tls, "allowInsecure")
+		proxy.ALPN = getStringList(tls, "alpn")
+	case "reality":
+		tls = getMap(stream, "realitySettings")
+		proxy.RealityOpts = &RealityOptions{
+			PublicKey: getString(tls, "publicKey"),
+			ShortID:   getString(tls, "shortId"),
+		}
+	default:
+		return fmt.Errorf("不支持的Xray安全类型: %s", security)
+	}
+	proxy.ClientFingerprint = getString(tls, "fingerprint")
+
+	// Clash中VMess/VLESS使用servername，其余协议使用sni
+	switch proxy.Type {
+	case "vmess", "vless":
+		proxy.TLS = true
+		proxy.ServerName = getString(tls, "serverName")
+	case "http", "socks5":
+		proxy.TLS = true
+		proxy.SNI = getString(tls, "serverName")
+	default:
+		proxy.SNI = getString(tls, "serverName")
+	}
+	return nil
+}
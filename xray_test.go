@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGenerateXrayConfigSelectors(t *testing.T) {
+	proxies := []ProxyConfig{
+		{Name: "HK", Type: "trojan", Server: "1.1.1.1", Port: 443, Password: "x"},
+		{Name: "HK-2", Type: "trojan", Server: "1.1.1.2", Port: 443, Password: "x"},
+	}
+	content, count, err := generateXrayConfig(proxies)
+	if err != nil || count != 2 {
+		t.Fatalf("generateXrayConfig() = %d nodes, error %v", count, err)
+	}
+
+	var config map[string]interface{}
+	if err := json.Unmarshal([]byte(content), &config); err != nil {
+		t.Fatal(err)
+	}
+	// 选择器按前缀匹配，只能选中节点出站，不能选中direct/block
+	selectors := [][]string{
+		getStringList(getMap(config, "observatory"), "subjectSelector"),
+		getStringList(getMapList(getMap(config, "routing"), "balancers")[0], "selector"),
+	}
+	for _, selector := range selectors {
+		for _, outbound := range getMapList(config, "outbounds") {
+			tag := getString(outbound, "tag")
+			selected := false
+			for _, prefix := range selector {
+				selected = selected || strings.HasPrefix(tag, prefix)
+			}
+			if isNode := getString(outbound, "protocol") == "trojan"; selected != isNode {
+				t.Errorf("selector %v selects %q = %v", selector, tag, selected)
+			}
+		}
+	}
+
+	parsed, err := parseXrayConfig(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, proxy := range parsed {
+		names = append(names, proxy.Name)
+	}
+	if want := []string{"HK", "HK-2"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("imported names = %v, want %v", names, want)
+	}
+}